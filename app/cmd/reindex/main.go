@@ -0,0 +1,76 @@
+// Command reindex recomputes the language and text search configuration of
+// all comments from comments.search in the config. Comments keep the
+// configuration they were written with, so run it after changing the search
+// languages or turning detection on or off.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"comment-tree/internal/config"
+	"comment-tree/internal/database"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/service"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func main() {
+	zlog.InitConsole()
+	log := zlog.Logger
+
+	configFilePath := os.Getenv("CONFIG_PATH")
+	if configFilePath == "" {
+		log.Fatal().Msg("CONFIG_PATH environment variable is not set")
+	}
+
+	cfg, err := config.Load(configFilePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load config")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	db, err := database.Connect(cfg.DB.URL, []string{}, &dbpg.Options{
+		MaxOpenConns:    1,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+	})
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("failed to connect to database")
+	}
+
+	strategy := retry.Strategy{
+		Attempts: cfg.Retry.Attempts,
+		Delay:    cfg.Retry.Delay,
+		Backoff:  cfg.Retry.Backoff,
+	}
+
+	svc := service.NewCommentsService(
+		repository.NewCommentsRepository(db, strategy),
+		repository.NewThreadsRepository(db, strategy),
+		repository.NewAuthorsRepository(db, strategy),
+		cfg.Comments,
+		&log,
+	)
+
+	changed, err := svc.Reindex(ctx)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Int64("changed", changed).
+			Msg("failed to reindex comments")
+	}
+
+	log.Info().
+		Int64("changed", changed).
+		Msg("comments reindexed")
+}
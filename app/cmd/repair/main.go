@@ -0,0 +1,65 @@
+// Command repair recomputes the reply counters of all comments. The service
+// keeps them up to date on its own; this is for fixing them after manual
+// changes to the database or a bug.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"comment-tree/internal/config"
+	"comment-tree/internal/database"
+	"comment-tree/internal/repository"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func main() {
+	zlog.InitConsole()
+	log := zlog.Logger
+
+	configFilePath := os.Getenv("CONFIG_PATH")
+	if configFilePath == "" {
+		log.Fatal().Msg("CONFIG_PATH environment variable is not set")
+	}
+
+	cfg, err := config.Load(configFilePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load config")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	db, err := database.Connect(cfg.DB.URL, []string{}, &dbpg.Options{
+		MaxOpenConns:    1,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+	})
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("failed to connect to database")
+	}
+
+	repo := repository.NewCommentsRepository(db, retry.Strategy{
+		Attempts: cfg.Retry.Attempts,
+		Delay:    cfg.Retry.Delay,
+		Backoff:  cfg.Retry.Backoff,
+	})
+
+	fixed, err := repo.RepairCounts(ctx)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("failed to repair reply counts")
+	}
+
+	log.Info().
+		Int64("fixed", fixed).
+		Msg("reply counts repaired")
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 
+	"comment-tree/internal/auth"
 	"comment-tree/internal/config"
 	"comment-tree/internal/database"
 	"comment-tree/internal/handler"
@@ -46,11 +47,33 @@ func NewCommentsTreeApp(cfg *config.Config, log *zlog.Zerolog) (*CommentsTreeApp
 		return nil, err
 	}
 
+	verifier, err := newVerifier(cfg.Auth)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to set up token verification")
+		return nil, err
+	}
+	if verifier == nil {
+		log.Warn().
+			Msg("auth is not configured, comments cannot be edited or deleted")
+	}
+
 	comRepo := repository.NewCommentsRepository(db, strategy)
+	threadRepo := repository.NewThreadsRepository(db, strategy)
+	authorRepo := repository.NewAuthorsRepository(db, strategy)
 
-	comService := service.NewCommentsService(comRepo, log)
+	comService := service.NewCommentsService(comRepo, threadRepo, authorRepo, cfg.Comments, log)
+	threadService := service.NewThreadsService(threadRepo, authorRepo, log)
+	authorService := service.NewAuthorsService(authorRepo, log)
+
+	if err := authorService.Bootstrap(context.Background(), cfg.Auth.BootstrapAdmin); err != nil {
+		return nil, err
+	}
 
 	comHandler := handler.NewCommentsHandler(comService, log)
+	threadHandler := handler.NewThreadsHandler(threadService, log)
+	authorHandler := handler.NewAuthorsHandler(authorService, log)
 
 	r.GET("/", func(c *ginext.Context) {
 		c.File("public/index.html")
@@ -58,8 +81,11 @@ func NewCommentsTreeApp(cfg *config.Config, log *zlog.Zerolog) (*CommentsTreeApp
 
 	r.Engine.Use(ginext.Logger())
 	r.Engine.Use(ginext.Recovery())
+	r.Engine.Use(handler.Authenticate(verifier))
 
 	comHandler.RegisterRoutes(r)
+	threadHandler.RegisterRoutes(r)
+	authorHandler.RegisterRoutes(r)
 
 	return &CommentsTreeApp{
 		cfg:    cfg,
@@ -68,6 +94,31 @@ func NewCommentsTreeApp(cfg *config.Config, log *zlog.Zerolog) (*CommentsTreeApp
 	}, nil
 }
 
+// newVerifier builds the bearer token verifier, nil when neither a secret nor
+// a JWKS file is configured.
+func newVerifier(cfg config.Auth) (*auth.Verifier, error) {
+	vcfg := auth.VerifierConfig{
+		Secret:   []byte(cfg.Secret),
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   cfg.Leeway,
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		vcfg.Keys = keys
+	}
+
+	if len(vcfg.Secret) == 0 && len(vcfg.Keys) == 0 {
+		return nil, nil
+	}
+
+	return auth.NewVerifier(vcfg)
+}
+
 func (a *CommentsTreeApp) Run(ctx context.Context) {
 	if err := a.engine.Run(":" + a.cfg.App.Port); err != nil {
 		a.log.Error().
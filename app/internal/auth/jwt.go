@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = fmt.Errorf("%w: expired", ErrInvalidToken)
+)
+
+// VerifierConfig describes which tokens are accepted. At least one of Secret
+// (HS256) and the keys of a JWKS file (RS256) has to be set.
+type VerifierConfig struct {
+	Secret   []byte
+	Keys     map[string]*rsa.PublicKey
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew between the issuer and this service.
+	Leeway time.Duration
+}
+
+// Verifier checks bearer JWTs and turns their claims into a Principal.
+type Verifier struct {
+	cfg VerifierConfig
+	now func() time.Time
+}
+
+func NewVerifier(cfg VerifierConfig) (*Verifier, error) {
+	if len(cfg.Secret) == 0 && len(cfg.Keys) == 0 {
+		return nil, errors.New("auth: neither a secret nor JWKS keys configured")
+	}
+
+	return &Verifier{cfg: cfg, now: time.Now}, nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+}
+
+// audience accepts both forms of the aud claim, a string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks the signature and the registered claims of token. The subject
+// must be the numeric id of an author.
+func (v *Verifier) Verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], sig); err != nil {
+		return Principal{}, err
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	if err := v.checkClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	authorID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || authorID <= 0 {
+		return Principal{}, fmt.Errorf("%w: subject is not an author id", ErrInvalidToken)
+	}
+
+	return Principal{AuthorID: authorID}, nil
+}
+
+// verifySignature only accepts the algorithm the matching key is meant for, so
+// an RSA public key can never be abused as an HMAC secret.
+func (v *Verifier) verifySignature(header tokenHeader, signed string, sig []byte) error {
+	switch header.Alg {
+	case "HS256":
+		if len(v.cfg.Secret) == 0 {
+			return ErrInvalidToken
+		}
+		mac := hmac.New(sha256.New, v.cfg.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrInvalidToken
+		}
+		return nil
+	case "RS256":
+		key := v.rsaKey(header.Kid)
+		if key == nil {
+			return ErrInvalidToken
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrInvalidToken
+		}
+		return nil
+	default:
+		return ErrInvalidToken
+	}
+}
+
+// rsaKey finds the key named by kid. A token without kid is accepted only when
+// the key set has a single key.
+func (v *Verifier) rsaKey(kid string) *rsa.PublicKey {
+	if kid != "" {
+		return v.cfg.Keys[kid]
+	}
+	if len(v.cfg.Keys) == 1 {
+		for _, key := range v.cfg.Keys {
+			return key
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) checkClaims(c tokenClaims) error {
+	now := v.now()
+
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(v.cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != nil && now.Add(v.cfg.Leeway).Before(time.Unix(*c.NotBefore, 0)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+
+	if v.cfg.Issuer != "" && c.Issuer != v.cfg.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.cfg.Audience != "" && !slices.Contains(c.Audience, v.cfg.Audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+type jwkSet struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// minRSABits is the shortest RSA modulus accepted for signing keys.
+const minRSABits = 2048
+
+// LoadJWKS reads the RSA signing keys of a local JWKS file by key id. Keys of
+// other types or uses are skipped.
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read jwks: %w", err)
+	}
+
+	var set jwkSet
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("auth: parse jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: invalid modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("auth: key %q: invalid exponent", k.Kid)
+		}
+
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minRSABits {
+			return nil, fmt.Errorf("auth: key %q: modulus shorter than %d bits", k.Kid, minRSABits)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: modulus,
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("auth: jwks has no RSA signing keys")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signHS256(t *testing.T, secret []byte, header, claims map[string]any) string {
+	t.Helper()
+
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	signed := encodeSegment(t, map[string]any{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestVerifier(t *testing.T, cfg VerifierConfig) *Verifier {
+	t.Helper()
+
+	v, err := NewVerifier(cfg)
+	require.NoError(t, err)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerifier_HS256(t *testing.T) {
+	secret := []byte("secret")
+	v := newTestVerifier(t, VerifierConfig{Secret: secret, Issuer: "comments", Audience: "api"})
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub": "42",
+			"iss": "comments",
+			"aud": []string{"web", "api"},
+			"exp": testNow.Add(time.Hour).Unix(),
+		}
+	}
+
+	p, err := v.Verify(signHS256(t, secret, hs256, valid()))
+	require.NoError(t, err)
+	require.Equal(t, Principal{AuthorID: 42}, p)
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"wrong secret", signHS256(t, []byte("other"), hs256, valid()), ErrInvalidToken},
+		{"alg none", signHS256(t, secret, map[string]any{"alg": "none"}, valid()), ErrInvalidToken},
+		{"expired", signHS256(t, secret, hs256, with(valid(), "exp", testNow.Add(-time.Hour).Unix())), ErrTokenExpired},
+		{"no exp", signHS256(t, secret, hs256, with(valid(), "exp", nil)), ErrInvalidToken},
+		{"not yet valid", signHS256(t, secret, hs256, with(valid(), "nbf", testNow.Add(time.Hour).Unix())), ErrInvalidToken},
+		{"issuer", signHS256(t, secret, hs256, with(valid(), "iss", "other")), ErrInvalidToken},
+		{"audience", signHS256(t, secret, hs256, with(valid(), "aud", "web")), ErrInvalidToken},
+		{"subject", signHS256(t, secret, hs256, with(valid(), "sub", "alice")), ErrInvalidToken},
+		{"malformed", "not.a.token", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func with(claims map[string]any, key string, value any) map[string]any {
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestVerifier_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := map[string]any{
+		"keys": []map[string]any{
+			{"kty": "EC", "kid": "ignored"},
+			{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		},
+	}
+	b, err := json.Marshal(jwks)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	keys, err := LoadJWKS(path)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	v := newTestVerifier(t, VerifierConfig{Keys: keys})
+	claims := map[string]any{"sub": "7", "exp": testNow.Add(time.Minute).Unix()}
+
+	p, err := v.Verify(signRS256(t, key, "k1", claims))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), p.AuthorID)
+
+	_, err = v.Verify(signRS256(t, key, "unknown", claims))
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// without a secret an HS256 token signed with the public modulus must fail
+	_, err = v.Verify(signHS256(t, key.N.Bytes(), map[string]any{"alg": "HS256", "kid": "k1"}, claims))
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestLoadJWKS_ShortKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": "short",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	_, err = LoadJWKS(path)
+	require.ErrorContains(t, err, "shorter than 2048 bits")
+}
@@ -0,0 +1,23 @@
+// Package auth carries the identity of the caller through a request.
+package auth
+
+import "context"
+
+// Principal is whoever performs the request. Its role is not part of the
+// token, it is looked up when a permission has to be checked.
+type Principal struct {
+	AuthorID int64
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request, false for anonymous requests.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,21 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"comment-tree/internal/auth"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrincipal(t *testing.T) {
+	_, ok := auth.FromContext(context.Background())
+	require.False(t, ok)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{AuthorID: 7})
+
+	p, ok := auth.FromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, int64(7), p.AuthorID)
+}
@@ -8,9 +8,11 @@ import (
 )
 
 type Config struct {
-	App   App      `mapstructure:"app"`
-	DB    Database `mapstructure:"database"`
-	Retry Retry    `mapstructure:"retry"`
+	App      App      `mapstructure:"app"`
+	DB       Database `mapstructure:"database"`
+	Retry    Retry    `mapstructure:"retry"`
+	Comments Comments `mapstructure:"comments"`
+	Auth     Auth     `mapstructure:"auth"`
 }
 
 type App struct {
@@ -26,6 +28,58 @@ type Database struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 }
 
+type Comments struct {
+	// DeletePolicy is used when a delete request does not pick one itself:
+	// cascade, tombstone or reparent. Empty means tombstone.
+	DeletePolicy string `mapstructure:"delete_policy"`
+	// MaxDepth limits how deep replies can nest, root comments have depth 0.
+	// Zero means no limit.
+	MaxDepth int `mapstructure:"max_depth"`
+	// Reactions is the set of reactions authors may put on comments, e.g.
+	// "thumbsup" or "heart". An empty set turns reactions off.
+	Reactions []string `mapstructure:"reactions"`
+	Search    Search   `mapstructure:"search"`
+}
+
+// Search names Postgres text search configurations, e.g. "russian" or
+// "english". New comments are indexed with Language, a search may pick any of
+// Languages and queries all of them when it picks none.
+type Search struct {
+	Language  string   `mapstructure:"language"`
+	Languages []string `mapstructure:"languages"`
+	// Detect guesses the language of every comment and indexes it with the
+	// matching configuration when that one is among Languages.
+	Detect    bool      `mapstructure:"detect"`
+	Highlight Highlight `mapstructure:"highlight"`
+}
+
+// Highlight configures the snippets of search results: matched words are
+// wrapped in StartSel and StopSel, and up to MaxFragments fragments of the
+// content are shown. Zero MaxFragments shows a single excerpt.
+type Highlight struct {
+	StartSel     string `mapstructure:"start_sel"`
+	StopSel      string `mapstructure:"stop_sel"`
+	MaxFragments int    `mapstructure:"max_fragments"`
+}
+
+// Auth configures bearer JWT validation: HS256 tokens are checked with Secret,
+// RS256 tokens with the keys of the local JWKS file.
+type Auth struct {
+	Secret   string        `mapstructure:"secret"`
+	JWKSFile string        `mapstructure:"jwks_file"`
+	Issuer   string        `mapstructure:"issuer"`
+	Audience string        `mapstructure:"audience"`
+	Leeway   time.Duration `mapstructure:"leeway"`
+	// BootstrapAdmin is made an admin on every start so that roles can be
+	// assigned on a fresh database. A zero ID disables it.
+	BootstrapAdmin BootstrapAdmin `mapstructure:"bootstrap_admin"`
+}
+
+type BootstrapAdmin struct {
+	ID          int64  `mapstructure:"id"`
+	DisplayName string `mapstructure:"display_name"`
+}
+
 type Retry struct {
 	Attempts int           `mapstructure:"attempts"`
 	Delay    time.Duration `mapstructure:"delay"`
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/service"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+type AuthorsHandler struct {
+	authorService *service.AuthorsService
+	log           *zlog.Zerolog
+}
+
+func NewAuthorsHandler(authorService *service.AuthorsService, log *zlog.Zerolog) *AuthorsHandler {
+	return &AuthorsHandler{
+		authorService: authorService,
+		log:           log,
+	}
+}
+
+func (h *AuthorsHandler) Create(c *ginext.Context) {
+	var a models.Author
+	if err := c.ShouldBindJSON(&a); err != nil {
+		h.log.Error().
+			Err(err).
+			Msg("failed to bind author")
+		badRequest(c, "malformed author body")
+		return
+	}
+
+	if err := models.Validate(&a); err != nil {
+		writeProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, err.Error())
+		return
+	}
+
+	if err := h.authorService.Create(c.Request.Context(), &a); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", a.ID).
+		Msg("author created")
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *AuthorsHandler) Get(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	a, err := h.authorService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *AuthorsHandler) SetRole(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Role models.Role `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		badRequest(c, "malformed role body")
+		return
+	}
+
+	if err := h.authorService.SetRole(c.Request.Context(), id, body.Role); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", id).
+		Str("role", string(body.Role)).
+		Msg("author role changed")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthorsHandler) RegisterRoutes(r *ginext.Engine) {
+	g := r.Group("/authors")
+
+	g.POST("/", h.Create)
+	g.GET("/:id", h.Get)
+	g.PUT("/:id/role", h.SetRole)
+}
+
+func (h *AuthorsHandler) writeError(c *ginext.Context, err error) {
+	respondError(c, h.log, err)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/searchql"
+	"comment-tree/internal/service"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error body. Code is a stable machine readable
+// identifier that clients can rely on instead of parsing Detail.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Field names the offending request field of a validation problem.
+	Field string `json:"field,omitempty"`
+	// Position is where a search query stopped parsing, in characters.
+	Position *int `json:"position,omitempty"`
+}
+
+const (
+	codeBadRequest         = "bad_request"
+	codeValidationFailed   = "validation_failed"
+	codeNotFound           = "not_found"
+	codeDuplicate          = "duplicate"
+	codeVersionConflict    = "version_conflict"
+	codePreconditionFailed = "precondition_failed"
+	codeInvalidReference   = "invalid_reference"
+	codeInvalidID          = "invalid_id"
+	codeInvalidValue       = "invalid_value"
+	codeMissingValue       = "missing_value"
+	codeInvalidPolicy      = "invalid_delete_policy"
+	codeInvalidCursor      = "invalid_cursor"
+	codeInvalidSort        = "invalid_sort"
+	codeInvalidThreadKey   = "invalid_thread_key"
+	codeThreadRequired     = "thread_required"
+	codeInvalidRole        = "invalid_role"
+	codeInvalidReaction    = "invalid_reaction"
+	codeInvalidLanguage    = "invalid_language"
+	codeInvalidQuery       = "invalid_query"
+	codeAmbiguousAuthor    = "ambiguous_author"
+	codeProfileRequired    = "profile_required"
+	codeUnauthorized       = "unauthorized"
+	codeForbidden          = "forbidden"
+	codeInternal           = "internal"
+)
+
+// errorMappings translates the sentinel errors of the lower layers to HTTP.
+// The first mapping the error matches with errors.Is wins.
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{repository.ErrNotFound, http.StatusNotFound, codeNotFound},
+	{repository.ErrConflict, http.StatusConflict, codeVersionConflict},
+	{repository.ErrDuplicate, http.StatusConflict, codeDuplicate},
+	{repository.ErrForeignKeyViolation, http.StatusUnprocessableEntity, codeInvalidReference},
+	{repository.ErrInvalidID, http.StatusUnprocessableEntity, codeInvalidID},
+	{repository.ErrInvalidValue, http.StatusUnprocessableEntity, codeInvalidValue},
+	{repository.ErrNilValue, http.StatusUnprocessableEntity, codeMissingValue},
+	{service.ErrInvalidDeletePolicy, http.StatusBadRequest, codeInvalidPolicy},
+	{models.ErrInvalidCursor, http.StatusBadRequest, codeInvalidCursor},
+	{models.ErrInvalidSort, http.StatusBadRequest, codeInvalidSort},
+	{models.ErrInvalidThreadKey, http.StatusBadRequest, codeInvalidThreadKey},
+	{service.ErrThreadRequired, http.StatusBadRequest, codeThreadRequired},
+	{service.ErrUnauthenticated, http.StatusUnauthorized, codeUnauthorized},
+	{service.ErrForbidden, http.StatusForbidden, codeForbidden},
+	{service.ErrInvalidRole, http.StatusBadRequest, codeInvalidRole},
+	{service.ErrInvalidReaction, http.StatusBadRequest, codeInvalidReaction},
+	{service.ErrInvalidLanguage, http.StatusBadRequest, codeInvalidLanguage},
+	{service.ErrAmbiguousAuthor, http.StatusBadRequest, codeAmbiguousAuthor},
+	{service.ErrProfileRequired, http.StatusForbidden, codeProfileRequired},
+}
+
+func (h *CommentsHandler) writeError(c *ginext.Context, err error) {
+	respondError(c, h.log, err)
+}
+
+// respondError responds with the problem matching err. Anything unknown becomes
+// a 500 without details so that database messages never reach the client.
+func respondError(c *ginext.Context, log *zlog.Zerolog, err error) {
+	var vErr *service.ValidationError
+	if errors.As(err, &vErr) {
+		p := newProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, vErr.Error())
+		p.Field = vErr.Field
+		writeJSONProblem(c, p)
+		return
+	}
+
+	var syntaxErr *searchql.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		p := newProblem(c, http.StatusBadRequest, codeInvalidQuery, syntaxErr.Error())
+		p.Position = &syntaxErr.Pos
+		writeJSONProblem(c, p)
+		return
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			writeProblem(c, m.status, m.code, err.Error())
+			return
+		}
+	}
+
+	log.Error().
+		Err(err).
+		Str("path", c.Request.URL.Path).
+		Msg("unhandled error")
+	writeProblem(c, http.StatusInternalServerError, codeInternal, "internal server error")
+}
+
+func writeProblem(c *ginext.Context, status int, code, detail string) {
+	writeJSONProblem(c, newProblem(c, status, code, detail))
+}
+
+func newProblem(c *ginext.Context, status int, code, detail string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+	}
+}
+
+func writeJSONProblem(c *ginext.Context, p Problem) {
+	c.Header("Content-Type", problemContentType)
+	c.JSON(p.Status, p)
+}
+
+func badRequest(c *ginext.Context, detail string) {
+	writeProblem(c, http.StatusBadRequest, codeBadRequest, detail)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"comment-tree/internal/repository"
+	"comment-tree/internal/searchql"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", repository.ErrNotFound, http.StatusNotFound, codeNotFound},
+		{"wrapped", fmt.Errorf("update: %w", repository.ErrConflict), http.StatusConflict, codeVersionConflict},
+		{"foreign key", repository.ErrForeignKeyViolation, http.StatusUnprocessableEntity, codeInvalidReference},
+		{"validation", &service.ValidationError{Field: "parent_id", Reason: "thread is locked"}, http.StatusUnprocessableEntity, codeValidationFailed},
+		{"search query", &searchql.SyntaxError{Pos: 5, Msg: "unterminated quoted phrase"}, http.StatusBadRequest, codeInvalidQuery},
+		{"ambiguous author", service.ErrAmbiguousAuthor, http.StatusBadRequest, codeAmbiguousAuthor},
+		{"unknown", errors.New(`pq: relation "comments" does not exist`), http.StatusInternalServerError, codeInternal},
+	}
+
+	h := &CommentsHandler{log: &zlog.Zerolog{}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ginext.New("release")
+			r.GET("/", func(c *ginext.Context) { h.writeError(c, tt.err) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+			var p Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			require.Equal(t, tt.status, p.Status)
+			require.Equal(t, tt.code, p.Code)
+			require.NotContains(t, p.Detail, "pq:")
+		})
+	}
+}
+
+func TestWriteError_SearchQueryPosition(t *testing.T) {
+	h := &CommentsHandler{log: &zlog.Zerolog{}}
+	r := ginext.New("release")
+	r.GET("/", func(c *ginext.Context) {
+		h.writeError(c, &searchql.SyntaxError{Pos: 0, Msg: "unterminated quoted phrase"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, codeInvalidQuery, p.Code)
+	require.NotNil(t, p.Position)
+	require.Zero(t, *p.Position)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+)
+
+// etag renders a comment version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// isWeakETag reports whether an If-Match header carries a weak entity tag.
+// If-Match uses the strong comparison, under which a weak tag never matches
+// (RFC 7232, section 3.1).
+func isWeakETag(header string) bool {
+	return strings.HasPrefix(strings.TrimSpace(header), "W/")
+}
+
+// parseIfMatch returns the comment version an If-Match header asks for.
+// "*" matches any version and is reported as 0. Weak tags are not versions.
+func parseIfMatch(header string) (int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, true
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int
+		ok      bool
+	}{
+		{header: `"3"`, version: 3, ok: true},
+		{header: ` "12" `, version: 12, ok: true},
+		{header: `W/"12"`, ok: false},
+		{header: `*`, version: 0, ok: true},
+		{header: `3`, ok: false},
+		{header: `"0"`, ok: false},
+		{header: `"abc"`, ok: false},
+		{header: `"`, ok: false},
+	}
+
+	for _, tt := range tests {
+		version, ok := parseIfMatch(tt.header)
+		require.Equal(t, tt.ok, ok, tt.header)
+		require.Equal(t, tt.version, version, tt.header)
+	}
+
+	v, ok := parseIfMatch(etag(7))
+	require.True(t, ok)
+	require.Equal(t, 7, v)
+}
+
+func TestIsWeakETag(t *testing.T) {
+	require.True(t, isWeakETag(`W/"3"`))
+	require.True(t, isWeakETag(` W/"3" `))
+	require.False(t, isWeakETag(`"3"`))
+	require.False(t, isWeakETag(`*`))
+}
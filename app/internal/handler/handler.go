@@ -1,16 +1,27 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/searchql"
 	"comment-tree/internal/service"
 
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
 
+const (
+	defaultTreeDepth = 3
+	maxTreeDepth     = 10
+	defaultLimit     = 10
+	maxLimit         = 100
+)
+
 type CommentsHandler struct {
 	commService *service.CommentsService
 	log         *zlog.Zerolog
@@ -33,51 +44,111 @@ func (h *CommentsHandler) Create(c *ginext.Context) {
 		h.log.Error().
 			Err(err).
 			Msg("failed to create comment")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		h.writeError(c, err)
 		return
 	}
 
 	h.log.Info().
 		Int64("id", com.ID).
 		Msg("comment created")
+	c.Header("ETag", etag(com.Version))
 	c.JSON(http.StatusOK, com)
 }
 
 func (h *CommentsHandler) Update(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
 	com, ok := h.getComment(c)
 	if !ok {
 		return
 	}
+	com.ID = id
+
+	// If-Match wins over a version sent in the body
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" {
+		if isWeakETag(ifMatch) {
+			writeProblem(c, http.StatusPreconditionFailed, codePreconditionFailed,
+				"weak entity tags never match If-Match")
+			return
+		}
+		version, ok := parseIfMatch(ifMatch)
+		if !ok {
+			badRequest(c, "invalid If-Match header")
+			return
+		}
+		com.Version = version
+	}
 
 	if err := h.commService.Update(c.Request.Context(), &com); err != nil {
+		if ifMatch != "" && errors.Is(err, repository.ErrConflict) {
+			writeProblem(c, http.StatusPreconditionFailed, codePreconditionFailed,
+				"comment was modified by someone else")
+			return
+		}
 		h.log.Error().
 			Err(err).
 			Int64("id", com.ID).
 			Msg("failed to update comment")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		h.writeError(c, err)
 		return
 	}
 
 	h.log.Info().
 		Int64("id", com.ID).
 		Msg("comment updated")
+	c.Header("ETag", etag(com.Version))
 	c.JSON(http.StatusOK, com)
 }
 
 func (h *CommentsHandler) Delete(c *ginext.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid id"})
+	id, ok := getID(c, "id")
+	if !ok {
 		return
 	}
 
-	if err := h.commService.Delete(c.Request.Context(), id); err != nil {
+	policy := models.DeletePolicy(c.Query("policy"))
+
+	res, err := h.commService.Delete(c.Request.Context(), id, policy)
+	if err != nil {
 		h.log.Error().
 			Err(err).
 			Int64("id", id).
 			Msg("failed to delete comment")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", id).
+		Str("policy", string(res.Policy)).
+		Int64("affected", res.Affected).
+		Msg("comment deleted")
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *CommentsHandler) Purge(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.commService.Purge(c.Request.Context(), id); err != nil {
+		h.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to purge comment")
+		h.writeError(c, err)
+		return
 	}
+
+	h.log.Info().
+		Int64("id", id).
+		Msg("comment purged")
+	c.Status(http.StatusNoContent)
 }
 
 func (h *CommentsHandler) GetByParent(c *ginext.Context) {
@@ -89,26 +160,35 @@ func (h *CommentsHandler) GetByParent(c *ginext.Context) {
 	} else {
 		id, err := strconv.ParseInt(parentStr, 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ginext.H{
-				"error": "invalid parent id",
-			})
+			badRequest(c, "invalid parent id")
 			return
 		}
 		parent = &id
 	}
 
-	limit, ok := h.getLimit(c)
+	threadID, ok := getThreadID(c)
+	if !ok {
+		return
+	}
+
+	sortBy, ok := getSort(c)
 	if !ok {
 		return
 	}
-	offset, ok := h.getOffset(c)
+
+	page, ok := getPage(c)
 	if !ok {
 		return
 	}
 
-	coms, err := h.commService.GetByParent(c.Request.Context(), parent, limit, offset)
+	coms, err := h.commService.GetByParent(c.Request.Context(), models.CommentsQuery{
+		ThreadID: threadID,
+		ParentID: parent,
+		Sort:     sortBy,
+		Page:     page,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		h.writeError(c, err)
 		return
 	}
 
@@ -116,24 +196,244 @@ func (h *CommentsHandler) GetByParent(c *ginext.Context) {
 }
 
 func (h *CommentsHandler) Search(c *ginext.Context) {
-	query := c.Query("query")
-	limit, ok := h.getLimit(c)
+	q, ok := getSearchQuery(c)
+	if !ok {
+		return
+	}
+
+	coms, err := h.commService.Search(c.Request.Context(), q)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, coms)
+}
+
+// Get returns a comment for a permalink view: its ancestors up to the root
+// and, with ?context=N, N levels of its replies.
+func (h *CommentsHandler) Get(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	depth, ok := getContextDepth(c)
 	if !ok {
 		return
 	}
-	offset, ok := h.getOffset(c)
+	limit, ok := getLimit(c)
 	if !ok {
 		return
 	}
 
-	coms, err := h.commService.Search(c.Request.Context(), query, limit, offset)
+	res, err := h.commService.GetPermalink(c.Request.Context(), id, depth, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ginext.H{
-			"error": err.Error(),
-		})
+		h.writeError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, coms)
+	c.Header("ETag", etag(res.Comment.Version))
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *CommentsHandler) GetTree(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	depth, ok := getDepth(c)
+	if !ok {
+		return
+	}
+	limit, ok := getLimit(c)
+	if !ok {
+		return
+	}
+
+	tree, err := h.commService.GetSubtree(c.Request.Context(), id, depth, limit)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+func (h *CommentsHandler) GetRevisions(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	revs, err := h.commService.GetRevisions(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revs)
+}
+
+func (h *CommentsHandler) GetRevision(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || rev <= 0 {
+		badRequest(c, "invalid revision")
+		return
+	}
+
+	res, err := h.commService.GetRevision(c.Request.Context(), id, rev)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *CommentsHandler) Lock(c *ginext.Context) {
+	h.setLocked(c, true)
+}
+
+func (h *CommentsHandler) Unlock(c *ginext.Context) {
+	h.setLocked(c, false)
+}
+
+func (h *CommentsHandler) setLocked(c *ginext.Context, locked bool) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.commService.SetLocked(c.Request.Context(), id, locked); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", id).
+		Bool("locked", locked).
+		Msg("comment lock changed")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CommentsHandler) Vote(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	var vote models.Vote
+	if err := c.ShouldBindJSON(&vote); err != nil {
+		badRequest(c, "malformed vote body")
+		return
+	}
+	if err := models.Validate(&vote); err != nil {
+		writeProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, "vote must be 1 or -1")
+		return
+	}
+
+	res, err := h.commService.Vote(c.Request.Context(), id, vote.Value)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *CommentsHandler) Unvote(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	res, err := h.commService.Unvote(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Move attaches a comment with its replies to the parent_id of the body, a
+// null parent_id turns it into a root comment.
+func (h *CommentsHandler) Move(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errors.Is(err, models.ErrParentRequired) {
+			badRequest(c, "parent_id is required, null makes the comment a root")
+			return
+		}
+		badRequest(c, "malformed move body")
+		return
+	}
+
+	com, err := h.commService.Move(c.Request.Context(), id, req.ParentID)
+	if err != nil {
+		h.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to move comment")
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", id).
+		Msg("comment moved")
+	c.JSON(http.StatusOK, com)
+}
+
+// Reactions lists the reactions that can be put on comments.
+func (h *CommentsHandler) Reactions(c *ginext.Context) {
+	reactions := h.commService.Reactions()
+	if reactions == nil {
+		reactions = []string{}
+	}
+	c.JSON(http.StatusOK, reactions)
+}
+
+func (h *CommentsHandler) AddReaction(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	res, err := h.commService.AddReaction(c.Request.Context(), id, c.Param("reaction"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *CommentsHandler) RemoveReaction(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	res, err := h.commService.RemoveReaction(c.Request.Context(), id, c.Param("reaction"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
 }
 
 func (h *CommentsHandler) RegisterRoutes(r *ginext.Engine) {
@@ -144,6 +444,33 @@ func (h *CommentsHandler) RegisterRoutes(r *ginext.Engine) {
 	g.DELETE("/:id", h.Delete)
 	g.GET("/", h.GetByParent)
 	g.GET("/search", h.Search)
+	g.GET("/:id", h.Get)
+	g.GET("/:id/tree", h.GetTree)
+	g.GET("/:id/revisions", h.GetRevisions)
+	g.GET("/:id/revisions/:rev", h.GetRevision)
+	g.POST("/:id/move", h.Move)
+	g.PUT("/:id/vote", h.Vote)
+	g.DELETE("/:id/vote", h.Unvote)
+	g.PUT("/:id/reactions/:reaction", h.AddReaction)
+	g.DELETE("/:id/reactions/:reaction", h.RemoveReaction)
+
+	r.GET("/reactions", h.Reactions)
+
+	// permissions of these routes are checked by the service
+	admin := r.Group("/admin")
+	admin.DELETE("/comments/:id", h.Purge)
+	admin.POST("/comments/:id/lock", h.Lock)
+	admin.DELETE("/comments/:id/lock", h.Unlock)
+}
+
+func getID(c *ginext.Context, param string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil || id <= 0 {
+		badRequest(c, "invalid "+param)
+		return 0, false
+	}
+
+	return id, true
 }
 
 func (h *CommentsHandler) getComment(c *ginext.Context) (models.Comment, bool) {
@@ -152,7 +479,7 @@ func (h *CommentsHandler) getComment(c *ginext.Context) (models.Comment, bool) {
 		h.log.Error().
 			Err(err).
 			Msg("failed to bind comment")
-		c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
+		badRequest(c, "malformed comment body")
 		return com, false
 	}
 
@@ -160,49 +487,200 @@ func (h *CommentsHandler) getComment(c *ginext.Context) (models.Comment, bool) {
 		h.log.Error().
 			Err(err).
 			Msg("invalid comment")
-		c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
+		writeProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, err.Error())
 		return com, false
 	}
 
 	return com, true
 }
 
-func (h *CommentsHandler) getLimit(c *ginext.Context) (int64, bool) {
-	limitStr := c.Query("limit")
-	var limit int64
-	var err error
+// getThreadID reads the optional thread_id query parameter, 0 when absent.
+func getThreadID(c *ginext.Context) (int64, bool) {
+	return getQueryID(c, "thread_id")
+}
+
+// getQueryID reads an optional id query parameter, 0 when absent.
+func getQueryID(c *ginext.Context, param string) (int64, bool) {
+	idStr := c.Query(param)
+	if idStr == "" {
+		return 0, true
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		badRequest(c, "invalid "+param)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// getSearchQuery reads the search query and its filters: thread_id,
+// author_id, under, created_after, created_before and roots_only.
+func getSearchQuery(c *ginext.Context) (models.SearchQuery, bool) {
+	q := models.SearchQuery{
+		Query: c.Query("query"),
+		Lang:  c.Query("lang"),
+	}
 
+	var ok bool
+	if q.ThreadID, ok = getThreadID(c); !ok {
+		return q, false
+	}
+	if q.AuthorID, ok = getQueryID(c, "author_id"); !ok {
+		return q, false
+	}
+	if q.Under, ok = getQueryID(c, "under"); !ok {
+		return q, false
+	}
+	if q.CreatedAfter, ok = getTime(c, "created_after"); !ok {
+		return q, false
+	}
+	if q.CreatedBefore, ok = getTime(c, "created_before"); !ok {
+		return q, false
+	}
+	if q.RootsOnly, ok = getBool(c, "roots_only"); !ok {
+		return q, false
+	}
+	if q.Page, ok = getPage(c); !ok {
+		return q, false
+	}
+
+	return q, true
+}
+
+// getTime reads an optional time query parameter, zero when absent. Both RFC
+// 3339 times and dates, meaning midnight UTC, are accepted.
+func getTime(c *ginext.Context, param string) (time.Time, bool) {
+	str := c.Query(param)
+	if str == "" {
+		return time.Time{}, true
+	}
+
+	t, err := searchql.ParseTime(str)
+	if err != nil {
+		badRequest(c, "invalid "+param)
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// getBool reads an optional boolean query parameter, false when absent.
+func getBool(c *ginext.Context, param string) (bool, bool) {
+	str := c.Query(param)
+	if str == "" {
+		return false, true
+	}
+
+	b, err := strconv.ParseBool(str)
+	if err != nil {
+		badRequest(c, "invalid "+param)
+		return false, false
+	}
+
+	return b, true
+}
+
+// getSort reads the sort query parameter, old when absent.
+func getSort(c *ginext.Context) (models.Sort, bool) {
+	sortStr := c.Query("sort")
+	if sortStr == "" {
+		return models.SortOld, true
+	}
+
+	sortBy := models.Sort(sortStr)
+	if !sortBy.Valid() {
+		writeProblem(c, http.StatusBadRequest, codeInvalidSort,
+			"sort must be one of old, new, top, controversial, hot")
+		return "", false
+	}
+
+	return sortBy, true
+}
+
+func getLimit(c *ginext.Context) (int64, bool) {
+	limitStr := c.Query("limit")
 	if limitStr == "" {
-		limit = 10
-	} else {
-		limit, err = strconv.ParseInt(limitStr, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, ginext.H{
-				"error": "invalid limit",
-			})
-			return 0, false
-		}
+		return defaultLimit, true
+	}
+
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit < 1 || limit > maxLimit {
+		badRequest(c, "invalid limit")
+		return 0, false
 	}
 
 	return limit, true
 }
 
-func (h *CommentsHandler) getOffset(c *ginext.Context) (int64, bool) {
-	offsetStr := c.Query("offset")
-	var offset int64
-	var err error
+// getPage reads limit and either an opaque cursor or, for older clients, an offset.
+func getPage(c *ginext.Context) (models.Page, bool) {
+	limit, ok := getLimit(c)
+	if !ok {
+		return models.Page{}, false
+	}
 
-	if offsetStr == "" {
-		offset = 0
-	} else {
-		offset, err = strconv.ParseInt(offsetStr, 10, 64)
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := models.DecodeCursor(cursorStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ginext.H{
-				"error": "invalid offset",
-			})
-			return 0, false
+			writeProblem(c, http.StatusBadRequest, codeInvalidCursor, err.Error())
+			return models.Page{}, false
 		}
+		return models.Page{Limit: limit, Cursor: cursor}, true
+	}
+
+	offset, ok := getOffset(c)
+	if !ok {
+		return models.Page{}, false
+	}
+
+	return models.Page{Limit: limit, Offset: offset}, true
+}
+
+func getOffset(c *ginext.Context) (int64, bool) {
+	offsetStr := c.Query("offset")
+	if offsetStr == "" {
+		return 0, true
+	}
+
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		badRequest(c, "invalid offset")
+		return 0, false
 	}
 
 	return offset, true
 }
+
+// getContextDepth reads how many levels of replies a permalink shows, none
+// when absent.
+func getContextDepth(c *ginext.Context) (int64, bool) {
+	depthStr := c.Query("context")
+	if depthStr == "" {
+		return 0, true
+	}
+
+	depth, err := strconv.ParseInt(depthStr, 10, 64)
+	if err != nil || depth < 0 || depth > maxTreeDepth {
+		badRequest(c, "invalid context")
+		return 0, false
+	}
+
+	return depth, true
+}
+
+func getDepth(c *ginext.Context) (int64, bool) {
+	depthStr := c.Query("depth")
+	if depthStr == "" {
+		return defaultTreeDepth, true
+	}
+
+	depth, err := strconv.ParseInt(depthStr, 10, 64)
+	if err != nil || depth < 0 || depth > maxTreeDepth {
+		badRequest(c, "invalid depth")
+		return 0, false
+	}
+
+	return depth, true
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"comment-tree/internal/models"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/ginext"
+)
+
+func TestGetSearchQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		want   models.SearchQuery
+		status int
+	}{
+		{
+			name: "all filters",
+			url: "/?query=go&thread_id=1&author_id=2&under=3&roots_only=true" +
+				"&created_after=2026-01-01&created_before=2026-02-01T10:00:00Z",
+			want: models.SearchQuery{
+				Query:         "go",
+				ThreadID:      1,
+				AuthorID:      2,
+				Under:         3,
+				CreatedAfter:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				CreatedBefore: time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC),
+				RootsOnly:     true,
+				Page:          models.Page{Limit: 10},
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "no filters",
+			url:    "/?query=go&lang=english",
+			want:   models.SearchQuery{Query: "go", Lang: "english", Page: models.Page{Limit: 10}},
+			status: http.StatusOK,
+		},
+		{name: "invalid author", url: "/?query=go&author_id=x", status: http.StatusBadRequest},
+		{name: "invalid time", url: "/?query=go&created_after=yesterday", status: http.StatusBadRequest},
+		{name: "invalid roots_only", url: "/?query=go&roots_only=maybe", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.SearchQuery
+			r := ginext.New("release")
+			r.GET("/", func(c *ginext.Context) {
+				q, ok := getSearchQuery(c)
+				if ok {
+					got = q
+					c.Status(http.StatusOK)
+				}
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		want   models.Page
+		status int
+	}{
+		{name: "default", url: "/", want: models.Page{Limit: defaultLimit}, status: http.StatusOK},
+		{name: "given", url: "/?limit=25&offset=50", want: models.Page{Limit: 25, Offset: 50}, status: http.StatusOK},
+		{name: "max limit", url: "/?limit=100", want: models.Page{Limit: maxLimit}, status: http.StatusOK},
+		{name: "zero limit", url: "/?limit=0", status: http.StatusBadRequest},
+		{name: "negative limit", url: "/?limit=-1", status: http.StatusBadRequest},
+		{name: "too large limit", url: "/?limit=101", status: http.StatusBadRequest},
+		{name: "limit not a number", url: "/?limit=ten", status: http.StatusBadRequest},
+		{name: "negative offset", url: "/?offset=-1", status: http.StatusBadRequest},
+		{name: "offset not a number", url: "/?offset=ten", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.Page
+			r := ginext.New("release")
+			r.GET("/", func(c *ginext.Context) {
+				page, ok := getPage(c)
+				if ok {
+					got = page
+					c.Status(http.StatusOK)
+				}
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMove_ParentRequired(t *testing.T) {
+	h := &CommentsHandler{}
+	r := ginext.New("release")
+	r.POST("/comments/:id/move", h.Move)
+
+	for _, body := range []string{`{}`, `{"parentId": 12}`} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/comments/1/move", strings.NewReader(body)))
+
+		require.Equal(t, http.StatusBadRequest, w.Code, body)
+		require.Contains(t, w.Body.String(), "parent_id is required", body)
+	}
+}
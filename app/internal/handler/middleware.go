@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"comment-tree/internal/auth"
+
+	"github.com/wb-go/wbf/ginext"
+)
+
+// Authenticate validates the bearer token of a request and puts its principal
+// into the request context. Requests without a token stay anonymous, so each
+// operation decides itself whether it needs an identity. A nil verifier means
+// authentication is not configured: tokens are ignored and every request is
+// anonymous.
+func Authenticate(verifier *auth.Verifier) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" || verifier == nil {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			unauthorized(c, "bearer token required")
+			c.Abort()
+			return
+		}
+
+		p, err := verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			unauthorized(c, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+func unauthorized(c *ginext.Context, detail string) {
+	c.Header("WWW-Authenticate", `Bearer realm="comments"`)
+	writeProblem(c, http.StatusUnauthorized, codeUnauthorized, detail)
+}
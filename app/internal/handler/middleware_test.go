@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"comment-tree/internal/auth"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/ginext"
+)
+
+func hs256Token(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticate(t *testing.T) {
+	secret := []byte("secret")
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{Secret: secret})
+	require.NoError(t, err)
+
+	valid := hs256Token(t, secret, map[string]any{"sub": "42", "exp": time.Now().Add(time.Hour).Unix()})
+	expired := hs256Token(t, secret, map[string]any{"sub": "42", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	tests := []struct {
+		name      string
+		verifier  *auth.Verifier
+		header    string
+		status    int
+		principal bool
+	}{
+		{"anonymous", verifier, "", http.StatusOK, false},
+		{"valid token", verifier, "Bearer " + valid, http.StatusOK, true},
+		{"expired token", verifier, "Bearer " + expired, http.StatusUnauthorized, false},
+		{"not bearer", verifier, "Basic dXNlcjpwYXNz", http.StatusUnauthorized, false},
+		{"not configured", nil, "Bearer " + valid, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ginext.New("release")
+			r.Use(Authenticate(tt.verifier))
+
+			var got auth.Principal
+			var ok bool
+			r.GET("/", func(c *ginext.Context) {
+				got, ok = auth.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.principal, ok)
+			if tt.principal {
+				require.Equal(t, int64(42), got.AuthorID)
+			}
+			if tt.status == http.StatusUnauthorized {
+				require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
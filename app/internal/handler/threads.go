@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/service"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+type ThreadsHandler struct {
+	threadService *service.ThreadsService
+	log           *zlog.Zerolog
+}
+
+func NewThreadsHandler(threadService *service.ThreadsService, log *zlog.Zerolog) *ThreadsHandler {
+	return &ThreadsHandler{
+		threadService: threadService,
+		log:           log,
+	}
+}
+
+func (h *ThreadsHandler) Create(c *ginext.Context) {
+	th, ok := h.getThread(c)
+	if !ok {
+		return
+	}
+
+	if err := h.threadService.Create(c.Request.Context(), &th); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", th.ID).
+		Str("key", th.Key).
+		Msg("thread created")
+	c.JSON(http.StatusOK, th)
+}
+
+func (h *ThreadsHandler) Update(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	th, ok := h.getThread(c)
+	if !ok {
+		return
+	}
+	th.ID = id
+
+	if err := h.threadService.Update(c.Request.Context(), &th); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", th.ID).
+		Msg("thread updated")
+	c.JSON(http.StatusOK, th)
+}
+
+func (h *ThreadsHandler) Delete(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.threadService.Delete(c.Request.Context(), id); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", id).
+		Msg("thread deleted")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ThreadsHandler) Get(c *ginext.Context) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	th, err := h.threadService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, th)
+}
+
+// Lookup finds the thread of an external object by its key, so that a page
+// only needs to know its own URL or identifier.
+func (h *ThreadsHandler) Lookup(c *ginext.Context) {
+	th, err := h.threadService.GetByKey(c.Request.Context(), c.Query("key"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, th)
+}
+
+func (h *ThreadsHandler) List(c *ginext.Context) {
+	page, ok := getPage(c)
+	if !ok {
+		return
+	}
+
+	threads, err := h.threadService.List(c.Request.Context(), page)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, threads)
+}
+
+func (h *ThreadsHandler) Lock(c *ginext.Context) {
+	h.setLocked(c, true)
+}
+
+func (h *ThreadsHandler) Unlock(c *ginext.Context) {
+	h.setLocked(c, false)
+}
+
+func (h *ThreadsHandler) setLocked(c *ginext.Context, locked bool) {
+	id, ok := getID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.threadService.SetLocked(c.Request.Context(), id, locked); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.log.Info().
+		Int64("id", id).
+		Bool("locked", locked).
+		Msg("thread lock changed")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ThreadsHandler) RegisterRoutes(r *ginext.Engine) {
+	g := r.Group("/threads")
+
+	g.POST("/", h.Create)
+	g.GET("/", h.List)
+	g.GET("/lookup", h.Lookup)
+	g.GET("/:id", h.Get)
+	g.POST("/:id", h.Update)
+	g.DELETE("/:id", h.Delete)
+
+	admin := r.Group("/admin")
+	admin.POST("/threads/:id/lock", h.Lock)
+	admin.DELETE("/threads/:id/lock", h.Unlock)
+}
+
+func (h *ThreadsHandler) writeError(c *ginext.Context, err error) {
+	respondError(c, h.log, err)
+}
+
+func (h *ThreadsHandler) getThread(c *ginext.Context) (models.Thread, bool) {
+	var th models.Thread
+	if err := c.ShouldBindJSON(&th); err != nil {
+		h.log.Error().
+			Err(err).
+			Msg("failed to bind thread")
+		badRequest(c, "malformed thread body")
+		return th, false
+	}
+
+	if err := models.Validate(&th); err != nil {
+		writeProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, err.Error())
+		return th, false
+	}
+
+	return th, true
+}
@@ -0,0 +1,155 @@
+// Package langdetect guesses the language of a text from its character
+// n-grams. Every language is described by the ranked list of its most
+// frequent n-grams, built from a small sample text, and a text is assigned to
+// the language whose list is closest to its own (Cavnar and Trenkle,
+// "N-Gram-Based Text Categorization").
+package langdetect
+
+import (
+	"cmp"
+	"embed"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxN is the longest n-gram, words are split into n-grams of 1 to maxN
+	// runes.
+	maxN = 3
+	// profileSize is how many of the most frequent n-grams make a profile.
+	profileSize = 300
+	// minLetters is the shortest text, in letters, a language is guessed for.
+	minLetters = 8
+)
+
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+// profile maps an n-gram to its rank, the most frequent n-gram has rank 0.
+type profile map[string]int
+
+// Detector picks the language of a text among the languages it has a
+// profile for. It is safe for concurrent use.
+type Detector struct {
+	langs    []string
+	profiles map[string]profile
+}
+
+// New returns a detector for the built in languages, named by their ISO 639-1
+// codes: "en" and "ru".
+func New() *Detector {
+	files, err := corpus.ReadDir("corpus")
+	if err != nil {
+		panic(err)
+	}
+
+	d := &Detector{profiles: make(map[string]profile, len(files))}
+	for _, f := range files {
+		text, err := corpus.ReadFile(path.Join("corpus", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		lang := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+		d.langs = append(d.langs, lang)
+		d.profiles[lang] = newProfile(string(text))
+	}
+
+	return d
+}
+
+// Detect returns the code of the language text is most likely written in, or
+// "" when the text is too short or shares nothing with any known language.
+func (d *Detector) Detect(text string) string {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+
+	doc := newProfile(text)
+	// a text unlike every language is as far from all of them as possible
+	best, bestDist := "", len(doc)*profileSize
+	for _, lang := range d.langs {
+		if dist := distance(doc, d.profiles[lang]); dist < bestDist {
+			best, bestDist = lang, dist
+		}
+	}
+
+	return best
+}
+
+// distance is the out-of-place measure: the sum over the n-grams of doc of how
+// far their rank is from the rank in lang, profileSize for n-grams lang lacks.
+func distance(doc, lang profile) int {
+	dist := 0
+	for gram, rank := range doc {
+		langRank, ok := lang[gram]
+		if !ok {
+			dist += profileSize
+			continue
+		}
+		if langRank > rank {
+			dist += langRank - rank
+		} else {
+			dist += rank - langRank
+		}
+	}
+	return dist
+}
+
+// newProfile ranks the n-grams of text by frequency and keeps the first
+// profileSize of them. Ties are ranked alphabetically so a profile does not
+// depend on map order.
+func newProfile(text string) profile {
+	counts := countNgrams(text)
+
+	grams := make([]string, 0, len(counts))
+	for gram := range counts {
+		grams = append(grams, gram)
+	}
+	slices.SortFunc(grams, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+
+	p := make(profile, len(grams))
+	for rank, gram := range grams {
+		p[gram] = rank
+	}
+	return p
+}
+
+// countNgrams counts the n-grams of every word of text. Words are lowercased
+// runs of letters padded with a space on both sides, so that n-grams at the
+// start and the end of a word differ from those inside it.
+func countNgrams(text string) map[string]int {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxN; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram == " " {
+					continue
+				}
+				counts[gram]++
+			}
+		}
+	}
+	return counts
+}
@@ -0,0 +1,33 @@
+package langdetect_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/langdetect"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"russian", "Подскажите, как перенести ветку обсуждения в другой тред?", "ru"},
+		{"english", "Could someone explain how to move a discussion to another thread?", "en"},
+		{"mostly russian", "Обновил docker образ, но сервис всё равно падает при запуске", "ru"},
+		{"mostly english", "The deploy failed again, see the лог above for details", "en"},
+		{"too short", "ok", ""},
+		{"no letters", "12345 67890 !!!", ""},
+		{"unknown script", "这是一个关于评论的讨论主题", ""},
+	}
+
+	d := langdetect.New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, d.Detect(tt.text))
+		})
+	}
+}
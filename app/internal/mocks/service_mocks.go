@@ -41,6 +41,20 @@ func (m *MockCommentsRepository) EXPECT() *MockCommentsRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddReaction mocks base method.
+func (m *MockCommentsRepository) AddReaction(ctx context.Context, commentID, authorID int64, reaction string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, commentID, authorID, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockCommentsRepositoryMockRecorder) AddReaction(ctx, commentID, authorID, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockCommentsRepository)(nil).AddReaction), ctx, commentID, authorID, reaction)
+}
+
 // Create mocks base method.
 func (m *MockCommentsRepository) Create(ctx context.Context, com *models.Comment) error {
 	m.ctrl.T.Helper()
@@ -56,47 +70,268 @@ func (mr *MockCommentsRepositoryMockRecorder) Create(ctx, com any) *gomock.Call
 }
 
 // Delete mocks base method.
-func (m *MockCommentsRepository) Delete(ctx context.Context, id int64) error {
+func (m *MockCommentsRepository) Delete(ctx context.Context, id int64, policy models.DeletePolicy, deletedBy *int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Delete", ctx, id, policy, deletedBy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockCommentsRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+func (mr *MockCommentsRepositoryMockRecorder) Delete(ctx, id, policy, deletedBy any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCommentsRepository)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCommentsRepository)(nil).Delete), ctx, id, policy, deletedBy)
 }
 
-// GetByParent mocks base method.
-func (m *MockCommentsRepository) GetByParent(ctx context.Context, parentID *int64, limit, offset int64) ([]*models.Comment, error) {
+// GetAncestors mocks base method.
+func (m *MockCommentsRepository) GetAncestors(ctx context.Context, id int64) ([]*models.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByParent", ctx, parentID, limit, offset)
+	ret := m.ctrl.Call(m, "GetAncestors", ctx, id)
 	ret0, _ := ret[0].([]*models.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetAncestors indicates an expected call of GetAncestors.
+func (mr *MockCommentsRepositoryMockRecorder) GetAncestors(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAncestors", reflect.TypeOf((*MockCommentsRepository)(nil).GetAncestors), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockCommentsRepository) GetByID(ctx context.Context, id int64) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockCommentsRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCommentsRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByParent mocks base method.
+func (m *MockCommentsRepository) GetByParent(ctx context.Context, q models.CommentsQuery) (*models.CommentsPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByParent", ctx, q)
+	ret0, _ := ret[0].(*models.CommentsPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // GetByParent indicates an expected call of GetByParent.
-func (mr *MockCommentsRepositoryMockRecorder) GetByParent(ctx, parentID, limit, offset any) *gomock.Call {
+func (mr *MockCommentsRepositoryMockRecorder) GetByParent(ctx, q any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByParent", reflect.TypeOf((*MockCommentsRepository)(nil).GetByParent), ctx, parentID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByParent", reflect.TypeOf((*MockCommentsRepository)(nil).GetByParent), ctx, q)
 }
 
-// Search mocks base method.
-func (m *MockCommentsRepository) Search(ctx context.Context, query string, limit, offset int64) ([]*models.Comment, error) {
+// GetHeight mocks base method.
+func (m *MockCommentsRepository) GetHeight(ctx context.Context, id int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHeight", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHeight indicates an expected call of GetHeight.
+func (mr *MockCommentsRepositoryMockRecorder) GetHeight(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHeight", reflect.TypeOf((*MockCommentsRepository)(nil).GetHeight), ctx, id)
+}
+
+// GetIndexBatch mocks base method.
+func (m *MockCommentsRepository) GetIndexBatch(ctx context.Context, afterID int64, limit int) ([]*models.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Search", ctx, query, limit, offset)
+	ret := m.ctrl.Call(m, "GetIndexBatch", ctx, afterID, limit)
 	ret0, _ := ret[0].([]*models.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetIndexBatch indicates an expected call of GetIndexBatch.
+func (mr *MockCommentsRepositoryMockRecorder) GetIndexBatch(ctx, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndexBatch", reflect.TypeOf((*MockCommentsRepository)(nil).GetIndexBatch), ctx, afterID, limit)
+}
+
+// GetReactions mocks base method.
+func (m *MockCommentsRepository) GetReactions(ctx context.Context, authorID *int64, commentIDs []int64) (map[int64][]models.Reaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReactions", ctx, authorID, commentIDs)
+	ret0, _ := ret[0].(map[int64][]models.Reaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReactions indicates an expected call of GetReactions.
+func (mr *MockCommentsRepositoryMockRecorder) GetReactions(ctx, authorID, commentIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReactions", reflect.TypeOf((*MockCommentsRepository)(nil).GetReactions), ctx, authorID, commentIDs)
+}
+
+// GetRevision mocks base method.
+func (m *MockCommentsRepository) GetRevision(ctx context.Context, commentID int64, rev int) (*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, commentID, rev)
+	ret0, _ := ret[0].(*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockCommentsRepositoryMockRecorder) GetRevision(ctx, commentID, rev any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockCommentsRepository)(nil).GetRevision), ctx, commentID, rev)
+}
+
+// GetRevisions mocks base method.
+func (m *MockCommentsRepository) GetRevisions(ctx context.Context, commentID int64) ([]*models.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", ctx, commentID)
+	ret0, _ := ret[0].([]*models.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockCommentsRepositoryMockRecorder) GetRevisions(ctx, commentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockCommentsRepository)(nil).GetRevisions), ctx, commentID)
+}
+
+// GetSubtree mocks base method.
+func (m *MockCommentsRepository) GetSubtree(ctx context.Context, rootID, maxDepth, perLevelLimit int64) (*models.CommentNode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubtree", ctx, rootID, maxDepth, perLevelLimit)
+	ret0, _ := ret[0].(*models.CommentNode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubtree indicates an expected call of GetSubtree.
+func (mr *MockCommentsRepositoryMockRecorder) GetSubtree(ctx, rootID, maxDepth, perLevelLimit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubtree", reflect.TypeOf((*MockCommentsRepository)(nil).GetSubtree), ctx, rootID, maxDepth, perLevelLimit)
+}
+
+// GetVotes mocks base method.
+func (m *MockCommentsRepository) GetVotes(ctx context.Context, authorID int64, commentIDs []int64) (map[int64]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVotes", ctx, authorID, commentIDs)
+	ret0, _ := ret[0].(map[int64]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVotes indicates an expected call of GetVotes.
+func (mr *MockCommentsRepositoryMockRecorder) GetVotes(ctx, authorID, commentIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVotes", reflect.TypeOf((*MockCommentsRepository)(nil).GetVotes), ctx, authorID, commentIDs)
+}
+
+// Move mocks base method.
+func (m *MockCommentsRepository) Move(ctx context.Context, id int64, parentID *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", ctx, id, parentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockCommentsRepositoryMockRecorder) Move(ctx, id, parentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockCommentsRepository)(nil).Move), ctx, id, parentID)
+}
+
+// Purge mocks base method.
+func (m *MockCommentsRepository) Purge(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockCommentsRepositoryMockRecorder) Purge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockCommentsRepository)(nil).Purge), ctx, id)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockCommentsRepository) RemoveReaction(ctx context.Context, commentID, authorID int64, reaction string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", ctx, commentID, authorID, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockCommentsRepositoryMockRecorder) RemoveReaction(ctx, commentID, authorID, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockCommentsRepository)(nil).RemoveReaction), ctx, commentID, authorID, reaction)
+}
+
+// Search mocks base method.
+func (m *MockCommentsRepository) Search(ctx context.Context, q models.SearchQuery) (*models.SearchPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, q)
+	ret0, _ := ret[0].(*models.SearchPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Search indicates an expected call of Search.
-func (mr *MockCommentsRepositoryMockRecorder) Search(ctx, query, limit, offset any) *gomock.Call {
+func (mr *MockCommentsRepositoryMockRecorder) Search(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockCommentsRepository)(nil).Search), ctx, q)
+}
+
+// SetIndex mocks base method.
+func (m *MockCommentsRepository) SetIndex(ctx context.Context, coms []*models.Comment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIndex", ctx, coms)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetIndex indicates an expected call of SetIndex.
+func (mr *MockCommentsRepositoryMockRecorder) SetIndex(ctx, coms any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIndex", reflect.TypeOf((*MockCommentsRepository)(nil).SetIndex), ctx, coms)
+}
+
+// SetLocked mocks base method.
+func (m *MockCommentsRepository) SetLocked(ctx context.Context, id int64, locked bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocked", ctx, id, locked)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLocked indicates an expected call of SetLocked.
+func (mr *MockCommentsRepositoryMockRecorder) SetLocked(ctx, id, locked any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocked", reflect.TypeOf((*MockCommentsRepository)(nil).SetLocked), ctx, id, locked)
+}
+
+// Unvote mocks base method.
+func (m *MockCommentsRepository) Unvote(ctx context.Context, commentID, authorID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unvote", ctx, commentID, authorID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unvote indicates an expected call of Unvote.
+func (mr *MockCommentsRepositoryMockRecorder) Unvote(ctx, commentID, authorID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockCommentsRepository)(nil).Search), ctx, query, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unvote", reflect.TypeOf((*MockCommentsRepository)(nil).Unvote), ctx, commentID, authorID)
 }
 
 // Update mocks base method.
@@ -112,3 +347,18 @@ func (mr *MockCommentsRepositoryMockRecorder) Update(ctx, com any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCommentsRepository)(nil).Update), ctx, com)
 }
+
+// Vote mocks base method.
+func (m *MockCommentsRepository) Vote(ctx context.Context, commentID, authorID int64, value int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", ctx, commentID, authorID, value)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockCommentsRepositoryMockRecorder) Vote(ctx, commentID, authorID, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockCommentsRepository)(nil).Vote), ctx, commentID, authorID, value)
+}
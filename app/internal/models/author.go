@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Role decides what an author may do beyond managing their own comments.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Author is the identity behind comments. Only the public profile is kept here.
+// Role can only be changed through role assignment, never by the author.
+type Author struct {
+	ID          int64     `json:"id"`
+	DisplayName string    `json:"display_name" validate:"required,max=100"`
+	AvatarURL   string    `json:"avatar_url,omitempty" validate:"omitempty,url,max=2048"`
+	Role        Role      `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -1,11 +1,16 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// DeletedContent replaces the text of a soft deleted comment.
+const DeletedContent = "[deleted]"
+
 var vld = validator.New()
 
 func Validate(modelsStruct interface{}) error {
@@ -13,8 +18,126 @@ func Validate(modelsStruct interface{}) error {
 }
 
 type Comment struct {
-	ID        int64     `json:"id"`
-	ParentID  *int64    `json:"parent_id"`
-	Content   string    `json:"content" validate:"required"`
-	CreatedAt time.Time `json:"created_at" validate:"required"`
+	ID       int64  `json:"id"`
+	ParentID *int64 `json:"parent_id"`
+	// ThreadID is required for root comments, replies inherit it from the parent.
+	ThreadID int64 `json:"thread_id"`
+	// AuthorID is taken from the request principal, nil for anonymous comments.
+	AuthorID *int64  `json:"author_id"`
+	Author   *Author `json:"author,omitempty"`
+	Content  string  `json:"content" validate:"required"`
+	// CreatedAt and UpdatedAt are set by the database, values sent by clients are ignored.
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	EditCount int        `json:"edit_count"`
+	// Version grows with every update. A non-zero Version on an update is the
+	// version the caller expects to overwrite.
+	Version  int        `json:"version"`
+	LockedAt *time.Time `json:"locked_at,omitempty"`
+	// Score is the sum of all votes, MyVote the vote of the caller: 1, -1 or 0
+	// when the caller has not voted.
+	Score  int `json:"score"`
+	MyVote int `json:"my_vote"`
+	// ReplyCount counts the direct replies, DescendantCount the whole subtree
+	// below the comment and LastReplyAt is the newest reply in it. Deleted
+	// replies that stay as tombstones are counted.
+	ReplyCount      int        `json:"reply_count"`
+	DescendantCount int        `json:"descendant_count"`
+	LastReplyAt     *time.Time `json:"last_reply_at,omitempty"`
+	// Reactions counts every reaction the comment got at least once.
+	Reactions []Reaction `json:"reactions,omitempty"`
+	// Lang is the ISO 639-1 code of the language the content is written in,
+	// empty when it is unknown.
+	Lang string `json:"lang,omitempty"`
+	// SearchConfig is the text search configuration the content is indexed
+	// with, DefaultSearchConfig when empty.
+	SearchConfig string `json:"-"`
+}
+
+// Tombstone hides the content and the author of a deleted comment. The comment
+// itself stays in place so that its replies are still reachable.
+func (c *Comment) Tombstone() {
+	if c.DeletedAt != nil {
+		c.Content = DeletedContent
+		c.AuthorID = nil
+		c.Author = nil
+	}
+}
+
+// CommentNode is a comment placed in a subtree together with its loaded replies.
+// More is set when the node has replies that were cut off by the depth or
+// per-level limit and have to be fetched separately.
+type CommentNode struct {
+	Comment
+	Depth    int64          `json:"depth"`
+	More     bool           `json:"more"`
+	Children []*CommentNode `json:"children"`
+}
+
+// ErrParentRequired is returned for a move body without parent_id.
+var ErrParentRequired = errors.New("parent_id is required")
+
+// MoveRequest names the new parent of a moved comment, nil to make it a root.
+// The key has to be present: a missing or misspelled parent_id would
+// otherwise read as null and silently turn the comment into a root.
+type MoveRequest struct {
+	ParentID *int64 `json:"parent_id"`
+}
+
+func (r *MoveRequest) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	parentID, ok := fields["parent_id"]
+	if !ok {
+		return ErrParentRequired
+	}
+	return json.Unmarshal(parentID, &r.ParentID)
+}
+
+// DeletePolicy decides what happens to the replies of a deleted comment.
+type DeletePolicy string
+
+const (
+	// DeletePolicyCascade removes the comment together with all of its replies.
+	DeletePolicyCascade DeletePolicy = "cascade"
+	// DeletePolicyTombstone keeps the comment as "[deleted]" with its replies in place.
+	DeletePolicyTombstone DeletePolicy = "tombstone"
+	// DeletePolicyReparent removes the comment and moves its replies up to its parent.
+	DeletePolicyReparent DeletePolicy = "reparent"
+)
+
+func (p DeletePolicy) Valid() bool {
+	switch p {
+	case DeletePolicyCascade, DeletePolicyTombstone, DeletePolicyReparent:
+		return true
+	}
+	return false
+}
+
+// DeleteResult reports how many descendants a delete removed or moved.
+type DeleteResult struct {
+	ID       int64        `json:"id"`
+	Policy   DeletePolicy `json:"policy"`
+	Affected int64        `json:"affected"`
+}
+
+// Revision is a superseded version of a comment: revision n keeps the text
+// that the n-th edit replaced.
+type Revision struct {
+	CommentID  int64     `json:"comment_id"`
+	Rev        int       `json:"rev"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// Permalink is a comment shown in its context: the chain of its parents
+// starting from the root, and the comment with some levels of its replies.
+type Permalink struct {
+	Ancestors []*Comment   `json:"ancestors"`
+	Comment   *CommentNode `json:"comment"`
 }
@@ -0,0 +1,42 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"comment-tree/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveRequest_UnmarshalJSON(t *testing.T) {
+	parentID := int64(12)
+
+	tests := []struct {
+		name string
+		body string
+		want models.MoveRequest
+		err  error
+	}{
+		{name: "parent", body: `{"parent_id": 12}`, want: models.MoveRequest{ParentID: &parentID}},
+		{name: "root", body: `{"parent_id": null}`, want: models.MoveRequest{}},
+		{name: "missing", body: `{}`, err: models.ErrParentRequired},
+		{name: "misspelled", body: `{"parentId": 12}`, err: models.ErrParentRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.MoveRequest
+			err := json.Unmarshal([]byte(tt.body), &got)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	var req models.MoveRequest
+	require.Error(t, json.Unmarshal([]byte(`{"parent_id": "x"}`), &req))
+}
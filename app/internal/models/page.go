@@ -0,0 +1,148 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// Cursor is a keyset position: the sort key of the last returned row and its
+// id as a tie-breaker. Listings sorted by time use CreatedAt, the other sorts
+// and search use Rank. Sort records the order the cursor was issued for.
+type Cursor struct {
+	CreatedAt time.Time `json:"c,omitzero"`
+	Rank      float64   `json:"r,omitzero"`
+	ID        int64     `json:"i"`
+	Sort      Sort      `json:"s,omitempty"`
+}
+
+// Encode returns the opaque token handed out to clients as next_cursor.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := new(Cursor)
+	if err := json.Unmarshal(b, c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// Page describes the requested slice of a listing. When Cursor is set it
+// takes precedence over Offset, which is kept for backward compatibility.
+type Page struct {
+	Limit  int64
+	Offset int64
+	Cursor *Cursor
+}
+
+type CommentsPage struct {
+	Items      []*Comment `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
+}
+
+// Sort is the order of a comment listing.
+type Sort string
+
+const (
+	// SortOld lists the oldest comments first.
+	SortOld Sort = "old"
+	// SortNew lists the newest comments first.
+	SortNew Sort = "new"
+	// SortTop lists comments by score.
+	SortTop Sort = "top"
+	// SortControversial lists comments with many and evenly split votes first.
+	SortControversial Sort = "controversial"
+	// SortHot lists comments by score with newer comments weighing more.
+	SortHot Sort = "hot"
+)
+
+func (s Sort) Valid() bool {
+	switch s {
+	case SortOld, SortNew, SortTop, SortControversial, SortHot:
+		return true
+	}
+	return false
+}
+
+// CommentsQuery selects the direct replies of ParentID, or the root comments
+// of ThreadID when ParentID is nil, in Sort order.
+type CommentsQuery struct {
+	ThreadID int64
+	ParentID *int64
+	Sort     Sort
+	Page     Page
+}
+
+// DefaultSearchConfig is the text search configuration used when neither the
+// comment nor the query names one.
+const DefaultSearchConfig = "russian"
+
+// SearchQuery is a full text search. Zero filters are not applied: ThreadID
+// limits it to one thread, AuthorID to the comments of one author, Under to
+// the replies below a comment at any depth, CreatedAfter and CreatedBefore to
+// comments written in between and RootsOnly to root comments. A query without
+// text lists the comments that match the filters, newest first.
+//
+// Lang is the text search configuration the caller asked for. Langs are the
+// configurations the query is parsed with: with a single one every comment is
+// matched against it, with several every comment is matched against the query
+// parsed with its own configuration.
+type SearchQuery struct {
+	Query         string
+	ThreadID      int64
+	AuthorID      int64
+	Under         int64
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	RootsOnly     bool
+	Lang          string
+	Langs         []string
+	Highlight     Highlight
+	Page          Page
+}
+
+// HasFilters reports whether any filter is set.
+func (q SearchQuery) HasFilters() bool {
+	return q.ThreadID != 0 || q.AuthorID != 0 || q.Under != 0 ||
+		!q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero() || q.RootsOnly
+}
+
+// Highlight describes the snippet of a search result: matched words are
+// wrapped in StartSel and StopSel and up to MaxFragments fragments of the
+// content are kept, a single excerpt when MaxFragments is zero.
+type Highlight struct {
+	StartSel     string
+	StopSel      string
+	MaxFragments int
+}
+
+// SearchResult is a comment found by a search. Highlight is the snippet of
+// its content that matched and Rank the relevance results are ordered by,
+// both are empty for a search by filters only.
+type SearchResult struct {
+	Comment   *Comment `json:"comment"`
+	Highlight string   `json:"highlight"`
+	Rank      float64  `json:"rank"`
+}
+
+type SearchPage struct {
+	Items      []*SearchResult `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
+}
@@ -0,0 +1,34 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"comment-tree/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursors := []models.Cursor{
+		{CreatedAt: time.Date(2025, 10, 1, 12, 30, 0, 123456000, time.UTC), ID: 42},
+		{Rank: 0.0607927, ID: 7},
+		{Rank: 38012.33717361111, ID: 9, Sort: models.SortHot},
+	}
+
+	for _, c := range cursors {
+		decoded, err := models.DecodeCursor(c.Encode())
+		require.NoError(t, err)
+		require.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+		require.Equal(t, c.Rank, decoded.Rank)
+		require.Equal(t, c.ID, decoded.ID)
+		require.Equal(t, c.Sort, decoded.Sort)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "bnVsbA", "e30"} {
+		_, err := models.DecodeCursor(token)
+		require.ErrorIs(t, err, models.ErrInvalidCursor, token)
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+// Reaction is how many authors reacted to a comment with Name, and whether
+// the caller is one of them.
+type Reaction struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Mine  bool   `json:"mine"`
+}
+
+// ReactionsResult is the state of the reactions of a comment right after the
+// caller added or removed one.
+type ReactionsResult struct {
+	CommentID int64      `json:"comment_id"`
+	Reactions []Reaction `json:"reactions"`
+}
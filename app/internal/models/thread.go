@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrInvalidThreadKey = errors.New("invalid thread key")
+
+// Thread attaches a comment tree to an external object identified by Key,
+// e.g. "article:123" or the normalized URL of a page.
+type Thread struct {
+	ID        int64          `json:"id"`
+	Key       string         `json:"key" validate:"required,max=2048"`
+	Title     string         `json:"title" validate:"max=512"`
+	Metadata  map[string]any `json:"metadata"`
+	LockedAt  *time.Time     `json:"locked_at,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+}
+
+type ThreadsPage struct {
+	Items      []*Thread `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// NormalizeThreadKey makes equal external identifiers compare equal. URLs get
+// a lower case scheme and host, no default port, fragment or trailing slash
+// and sorted query parameters; other keys are only trimmed.
+func NormalizeThreadKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", ErrInvalidThreadKey
+	}
+
+	u, err := url.Parse(key)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return key, nil
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = u.Query().Encode()
+	u.User = nil
+
+	return u.String(), nil
+}
@@ -0,0 +1,31 @@
+package models_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeThreadKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: " article:123 ", want: "article:123"},
+		{key: "HTTPS://Example.com:443/blog/post/?b=2&a=1#comments", want: "https://example.com/blog/post?a=1&b=2"},
+		{key: "http://example.com:8080/", want: "http://example.com:8080"},
+		{key: "http://example.com/", want: "http://example.com"},
+		{key: "ftp://example.com/file", want: "ftp://example.com/file"},
+	}
+
+	for _, tt := range tests {
+		got, err := models.NormalizeThreadKey(tt.key)
+		require.NoError(t, err, tt.key)
+		require.Equal(t, tt.want, got, tt.key)
+	}
+
+	_, err := models.NormalizeThreadKey("   ")
+	require.ErrorIs(t, err, models.ErrInvalidThreadKey)
+}
@@ -0,0 +1,14 @@
+package models
+
+// Vote is an up (1) or down (-1) vote of the caller on a comment.
+type Vote struct {
+	Value int `json:"value" validate:"oneof=-1 1"`
+}
+
+// VoteResult is the state of a comment right after the caller voted or took
+// the vote back.
+type VoteResult struct {
+	CommentID int64 `json:"comment_id"`
+	Score     int   `json:"score"`
+	MyVote    int   `json:"my_vote"`
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"comment-tree/internal/models"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+)
+
+var authorColumns = []string{"id", "display_name", "avatar_url", "role", "created_at"}
+
+type AuthorsRepository struct {
+	db       *dbpg.DB
+	strategy retry.Strategy
+	sb       squirrel.StatementBuilderType
+}
+
+func NewAuthorsRepository(db *dbpg.DB, strategy retry.Strategy) *AuthorsRepository {
+	return &AuthorsRepository{
+		db:       db,
+		strategy: strategy,
+		sb:       squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Create stores a new author. A zero a.ID draws the id from the sequence,
+// otherwise the author gets a.ID, the subject of their token.
+func (r *AuthorsRepository) Create(ctx context.Context, a *models.Author) error {
+	if a == nil {
+		return ErrNilValue
+	}
+
+	if a.ID != 0 {
+		return inTx(ctx, r.db, func(tx *sql.Tx) error {
+			err := tx.QueryRowContext(ctx, `
+			INSERT INTO authors (id, display_name, avatar_url)
+			VALUES ($1, $2, $3)
+			RETURNING role, created_at`,
+				a.ID, a.DisplayName, a.AvatarURL,
+			).Scan(&a.Role, &a.CreatedAt)
+			if err != nil {
+				return err
+			}
+
+			// keep ids drawn later from colliding with this one
+			_, err = tx.ExecContext(ctx, `
+			SELECT setval(pg_get_serial_sequence('authors', 'id'), GREATEST(max(id), 1))
+			FROM authors`)
+			return err
+		})
+	}
+
+	query := r.sb.Insert("authors").
+		Columns("display_name", "avatar_url").
+		Values(a.DisplayName, a.AvatarURL).
+		Suffix("RETURNING id, role, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return wrapDBError(
+		row.Scan(&a.ID, &a.Role, &a.CreatedAt),
+	)
+}
+
+func (r *AuthorsRepository) GetByID(ctx context.Context, id int64) (*models.Author, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+
+	sql, args, err := r.sb.Select(authorColumns...).From("authors").Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	a := &models.Author{}
+	if err := scanAuthor(row, a); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return a, nil
+}
+
+// GetByIDs loads several authors in one query. Unknown ids are skipped.
+func (r *AuthorsRepository) GetByIDs(ctx context.Context, ids []int64) ([]*models.Author, error) {
+	if len(ids) == 0 {
+		return []*models.Author{}, nil
+	}
+
+	sql, args, err := r.sb.Select(authorColumns...).From("authors").Where(squirrel.Eq{"id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	authors := []*models.Author{}
+	for rows.Next() {
+		a := &models.Author{}
+		if err := scanAuthor(rows, a); err != nil {
+			return nil, wrapDBError(err)
+		}
+		authors = append(authors, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return authors, nil
+}
+
+// GetByDisplayName loads the authors whose display name equals name ignoring
+// case. Display names are not unique, so there may be several.
+func (r *AuthorsRepository) GetByDisplayName(ctx context.Context, name string) ([]*models.Author, error) {
+	sql, args, err := r.sb.Select(authorColumns...).
+		From("authors").
+		Where("lower(display_name) = lower(?)", name).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	authors := []*models.Author{}
+	for rows.Next() {
+		a := &models.Author{}
+		if err := scanAuthor(rows, a); err != nil {
+			return nil, wrapDBError(err)
+		}
+		authors = append(authors, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return authors, nil
+}
+
+// SetRole changes the role of an author.
+func (r *AuthorsRepository) SetRole(ctx context.Context, id int64, role models.Role) error {
+	if id == 0 {
+		return ErrInvalidID
+	}
+
+	sql, args, err := r.sb.Update("authors").
+		Set("role", role).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// EnsureAdmin creates the author a.ID as an admin or promotes it when it
+// already exists. The id sequence is moved past a.ID so that later authors do
+// not collide with it.
+func (r *AuthorsRepository) EnsureAdmin(ctx context.Context, a *models.Author) error {
+	if a == nil || a.ID == 0 {
+		return ErrNilValue
+	}
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+		INSERT INTO authors (id, display_name, role)
+		VALUES ($1, $2, 'admin')
+		ON CONFLICT (id) DO UPDATE SET role = 'admin'
+		RETURNING display_name, avatar_url, role, created_at`,
+			a.ID, a.DisplayName,
+		).Scan(&a.DisplayName, &a.AvatarURL, &a.Role, &a.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		SELECT setval(pg_get_serial_sequence('authors', 'id'), GREATEST(max(id), 1))
+		FROM authors`)
+		return err
+	})
+}
+
+func scanAuthor(row rowScanner, a *models.Author) error {
+	return row.Scan(&a.ID, &a.DisplayName, &a.AvatarURL, &a.Role, &a.CreatedAt)
+}
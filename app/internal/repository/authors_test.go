@@ -0,0 +1,109 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorsRepository(t *testing.T) {
+	repo := repository.NewAuthorsRepository(db, strategy)
+	comments := repository.NewCommentsRepository(db, strategy)
+
+	alice := models.Author{DisplayName: "Alice", AvatarURL: "https://example.com/alice.png"}
+	require.NoError(t, repo.Create(t.Context(), &alice))
+
+	bob := models.Author{DisplayName: "Bob"}
+	require.NoError(t, repo.Create(t.Context(), &bob))
+
+	t.Run("get by ids", func(t *testing.T) {
+		authors, err := repo.GetByIDs(t.Context(), []int64{alice.ID, bob.ID, -1})
+		require.NoError(t, err)
+		require.Len(t, authors, 2)
+	})
+
+	t.Run("get by display name", func(t *testing.T) {
+		name := fmt.Sprintf("Carol %d", time.Now().UnixNano())
+		carol := models.Author{DisplayName: name}
+		require.NoError(t, repo.Create(t.Context(), &carol))
+
+		authors, err := repo.GetByDisplayName(t.Context(), strings.ToUpper(name))
+		require.NoError(t, err)
+		require.Len(t, authors, 1)
+		require.Equal(t, carol.ID, authors[0].ID)
+
+		authors, err = repo.GetByDisplayName(t.Context(), "nobody")
+		require.NoError(t, err)
+		require.Empty(t, authors)
+	})
+
+	t.Run("comment author", func(t *testing.T) {
+		com := models.Comment{ThreadID: newThread(t), AuthorID: &alice.ID, Content: "signed"}
+		require.NoError(t, comments.Create(t.Context(), &com))
+		require.Equal(t, alice.ID, *com.AuthorID)
+
+		_, err := comments.Delete(t.Context(), com.ID, models.DeletePolicyTombstone, &bob.ID)
+		require.NoError(t, err)
+
+		var deletedBy int64
+		require.NoError(t, db.QueryRowContext(t.Context(),
+			`SELECT deleted_by FROM comments WHERE id = $1`, com.ID,
+		).Scan(&deletedBy))
+		require.Equal(t, bob.ID, deletedBy)
+	})
+
+	t.Run("unknown author", func(t *testing.T) {
+		unknown := int64(-1)
+		com := models.Comment{ThreadID: newThread(t), AuthorID: &unknown, Content: "forged"}
+		err := comments.Create(t.Context(), &com)
+		require.ErrorIs(t, err, repository.ErrForeignKeyViolation)
+	})
+	t.Run("roles", func(t *testing.T) {
+		require.Equal(t, models.RoleUser, bob.Role)
+
+		require.NoError(t, repo.SetRole(t.Context(), bob.ID, models.RoleModerator))
+		got, err := repo.GetByID(t.Context(), bob.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.RoleModerator, got.Role)
+
+		err = repo.SetRole(t.Context(), -1, models.RoleAdmin)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("create with id", func(t *testing.T) {
+		own := models.Author{ID: bob.ID + 2000, DisplayName: "Dave"}
+		require.NoError(t, repo.Create(t.Context(), &own))
+		require.Equal(t, models.RoleUser, own.Role)
+
+		again := models.Author{ID: own.ID, DisplayName: "Dave"}
+		require.ErrorIs(t, repo.Create(t.Context(), &again), repository.ErrDuplicate)
+
+		next := models.Author{DisplayName: "after"}
+		require.NoError(t, repo.Create(t.Context(), &next))
+		require.Greater(t, next.ID, own.ID)
+	})
+
+	t.Run("ensure admin", func(t *testing.T) {
+		existing := models.Author{ID: alice.ID, DisplayName: "ignored"}
+		require.NoError(t, repo.EnsureAdmin(t.Context(), &existing))
+		require.Equal(t, "Alice", existing.DisplayName)
+		require.Equal(t, models.RoleAdmin, existing.Role)
+
+		fresh := models.Author{ID: bob.ID + 1000, DisplayName: "root"}
+		require.NoError(t, repo.EnsureAdmin(t.Context(), &fresh))
+		require.Equal(t, models.RoleAdmin, fresh.Role)
+
+		next := models.Author{DisplayName: "after"}
+		require.NoError(t, repo.Create(t.Context(), &next))
+		require.Greater(t, next.ID, fresh.ID)
+	})
+}
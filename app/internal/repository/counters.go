@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ancestorChain returns id followed by its ancestors up to the root, nearest
+// first. The rows are locked in id order, which keeps concurrent counter
+// updates on overlapping chains from deadlocking.
+func ancestorChain(ctx context.Context, tx *sql.Tx, id int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT a.id, nlevel(a.path)
+	FROM comments c
+	JOIN comments a ON a.path @> c.path
+	WHERE c.id = $1
+	ORDER BY a.id
+	FOR UPDATE OF a`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chain []int64
+	levels := make(map[int64]int64)
+	for rows.Next() {
+		var ancestorID, level int64
+		if err := rows.Scan(&ancestorID, &level); err != nil {
+			return nil, err
+		}
+		chain = append(chain, ancestorID)
+		levels[ancestorID] = level
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(chain, func(a, b int64) int {
+		return cmp.Compare(levels[b], levels[a])
+	})
+
+	return chain, nil
+}
+
+// lockChains locks the comments ids together with all of their ancestors in
+// a single statement, in id order. Transactions that change the tree take it
+// before any other row lock: Create locks the chain of the new parent the same
+// way, so two writers meeting on a shared ancestor always queue up in the same
+// order instead of deadlocking.
+func lockChains(ctx context.Context, tx *sql.Tx, ids ...int64) error {
+	_, err := tx.ExecContext(ctx, `
+	SELECT id
+	FROM comments
+	WHERE id IN (
+		SELECT a.id
+		FROM comments c
+		JOIN comments a ON a.path @> c.path
+		WHERE c.id = ANY($1)
+	)
+	ORDER BY id
+	FOR UPDATE`, pq.Array(ids),
+	)
+	return err
+}
+
+// adjustCounts changes the counters of chain, a comment followed by its
+// ancestors: replies is added to the reply count of the first comment only,
+// descendants to the descendant count of all of them.
+func adjustCounts(ctx context.Context, tx *sql.Tx, chain []int64, replies, descendants int64) error {
+	if len(chain) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+	UPDATE comments
+	SET reply_count = reply_count + CASE WHEN id = $1 THEN $2::int ELSE 0 END,
+		descendant_count = descendant_count + $3::int
+	WHERE id = ANY($4)`,
+		chain[0], replies, descendants, pq.Array(chain),
+	)
+	return err
+}
+
+// addReplies counts a reply with descendants comments in its subtree, itself
+// included, below parentID. lastReplyAt is the newest of them.
+func addReplies(ctx context.Context, tx *sql.Tx, parentID int64, descendants int64, lastReplyAt time.Time) error {
+	chain, err := ancestorChain(ctx, tx, parentID)
+	if err != nil {
+		return err
+	}
+
+	return countReply(ctx, tx, chain, descendants, lastReplyAt)
+}
+
+// countReply is addReplies for a chain the caller has already locked.
+func countReply(ctx context.Context, tx *sql.Tx, chain []int64, descendants int64, lastReplyAt time.Time) error {
+	if err := adjustCounts(ctx, tx, chain, 1, descendants); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+	UPDATE comments
+	SET last_reply_at = GREATEST(last_reply_at, $1)
+	WHERE id = ANY($2)`,
+		lastReplyAt, pq.Array(chain),
+	)
+	return err
+}
+
+// refreshLastReply recomputes last_reply_at of chain after replies went away.
+// Every comment takes the newest of its direct replies and their own
+// last_reply_at, so the chain is walked from the nearest comment up.
+func refreshLastReply(ctx context.Context, tx *sql.Tx, chain []int64) error {
+	for _, id := range chain {
+		_, err := tx.ExecContext(ctx, `
+		UPDATE comments
+		SET last_reply_at = (
+			SELECT max(GREATEST(r.created_at, r.last_reply_at))
+			FROM comments r
+			WHERE r.parent_id = $1
+		)
+		WHERE id = $1`, id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeReplies updates the counters above parentID after replies direct
+// replies and descendants comments in total left its subtree. A nil parentID
+// means the comments were roots and nothing has to be updated.
+func removeReplies(ctx context.Context, tx *sql.Tx, parentID *int64, replies, descendants int64) error {
+	if parentID == nil {
+		return nil
+	}
+
+	chain, err := ancestorChain(ctx, tx, *parentID)
+	if err != nil {
+		return err
+	}
+
+	if err := adjustCounts(ctx, tx, chain, -replies, -descendants); err != nil {
+		return err
+	}
+
+	return refreshLastReply(ctx, tx, chain)
+}
+
+// RepairCounts recomputes reply_count, descendant_count and last_reply_at of
+// every comment from the tree itself and returns how many comments were
+// wrong.
+func (r *CommentsRepository) RepairCounts(ctx context.Context) (int64, error) {
+	const sqlQuery = `
+	WITH RECURSIVE sub (ancestor_id, created_at) AS (
+		SELECT parent_id, created_at FROM comments WHERE parent_id IS NOT NULL
+		UNION ALL
+		SELECT p.parent_id, s.created_at
+		FROM sub s
+		JOIN comments p ON p.id = s.ancestor_id
+		WHERE p.parent_id IS NOT NULL
+	), agg AS (
+		SELECT ancestor_id, count(*) AS descendants, max(created_at) AS last_reply_at
+		FROM sub
+		GROUP BY ancestor_id
+	), direct AS (
+		SELECT parent_id, count(*) AS replies
+		FROM comments
+		WHERE parent_id IS NOT NULL
+		GROUP BY parent_id
+	), expected AS (
+		SELECT c.id,
+			COALESCE(d.replies, 0) AS reply_count,
+			COALESCE(a.descendants, 0) AS descendant_count,
+			a.last_reply_at
+		FROM comments c
+		LEFT JOIN agg a ON a.ancestor_id = c.id
+		LEFT JOIN direct d ON d.parent_id = c.id
+	)
+	UPDATE comments c
+	SET reply_count = e.reply_count,
+		descendant_count = e.descendant_count,
+		last_reply_at = e.last_reply_at
+	FROM expected e
+	WHERE c.id = e.id
+		AND (c.reply_count, c.descendant_count, c.last_reply_at)
+			IS DISTINCT FROM (e.reply_count, e.descendant_count, e.last_reply_at);
+	`
+
+	var fixed int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		// counters must not change under the recount
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE comments IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, sqlQuery)
+		if err != nil {
+			return err
+		}
+
+		fixed, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return fixed, nil
+}
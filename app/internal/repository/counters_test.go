@@ -0,0 +1,259 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"sync"
+	"testing"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsRepository_ReplyCounts(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	create := func(parentID *int64) *models.Comment {
+		t.Helper()
+		com := &models.Comment{ThreadID: threadID, ParentID: parentID, Content: "counted"}
+		require.NoError(t, repo.Create(t.Context(), com))
+		return com
+	}
+	counts := func(id int64) (int, int) {
+		t.Helper()
+		com, err := repo.GetByID(t.Context(), id)
+		require.NoError(t, err)
+		return com.ReplyCount, com.DescendantCount
+	}
+
+	// root
+	// ├── a
+	// │   ├── a1
+	// │   └── a2
+	// │       └── a2x
+	// └── b
+	root := create(nil)
+	a := create(&root.ID)
+	a1 := create(&a.ID)
+	a2 := create(&a.ID)
+	a2x := create(&a2.ID)
+	b := create(&root.ID)
+
+	replies, descendants := counts(root.ID)
+	require.Equal(t, 2, replies)
+	require.Equal(t, 5, descendants)
+
+	replies, descendants = counts(a.ID)
+	require.Equal(t, 2, replies)
+	require.Equal(t, 3, descendants)
+
+	got, err := repo.GetByID(t.Context(), root.ID)
+	require.NoError(t, err)
+	require.True(t, b.CreatedAt.Equal(*got.LastReplyAt))
+
+	t.Run("tombstone keeps counts", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), a1.ID, models.DeletePolicyTombstone, nil)
+		require.NoError(t, err)
+
+		replies, descendants := counts(a.ID)
+		require.Equal(t, 2, replies)
+		require.Equal(t, 3, descendants)
+	})
+
+	t.Run("reparent", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), a2.ID, models.DeletePolicyReparent, nil)
+		require.NoError(t, err)
+
+		// a2x moved up to a
+		replies, descendants := counts(a.ID)
+		require.Equal(t, 2, replies)
+		require.Equal(t, 2, descendants)
+
+		replies, descendants = counts(root.ID)
+		require.Equal(t, 2, replies)
+		require.Equal(t, 4, descendants)
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), b.ID, models.DeletePolicyCascade, nil)
+		require.NoError(t, err)
+
+		replies, descendants := counts(root.ID)
+		require.Equal(t, 1, replies)
+		require.Equal(t, 3, descendants)
+
+		// b was the newest reply, a2x is now
+		got, err := repo.GetByID(t.Context(), root.ID)
+		require.NoError(t, err)
+		require.True(t, a2x.CreatedAt.Equal(*got.LastReplyAt))
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		require.NoError(t, repo.Purge(t.Context(), a.ID))
+
+		replies, descendants := counts(root.ID)
+		require.Zero(t, replies)
+		require.Zero(t, descendants)
+
+		got, err := repo.GetByID(t.Context(), root.ID)
+		require.NoError(t, err)
+		require.Nil(t, got.LastReplyAt)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		c := create(&root.ID)
+		_, err := db.ExecContext(t.Context(),
+			`UPDATE comments SET reply_count = 7, descendant_count = 9, last_reply_at = NULL WHERE id = $1`, root.ID,
+		)
+		require.NoError(t, err)
+
+		fixed, err := repo.RepairCounts(t.Context())
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, fixed, int64(1))
+
+		replies, descendants := counts(root.ID)
+		require.Equal(t, 1, replies)
+		require.Equal(t, 1, descendants)
+
+		got, err := repo.GetByID(t.Context(), root.ID)
+		require.NoError(t, err)
+		require.True(t, c.CreatedAt.Equal(*got.LastReplyAt))
+
+		fixed, err = repo.RepairCounts(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, fixed)
+	})
+}
+
+func TestCommentsRepository_Move(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	create := func(parentID *int64) *models.Comment {
+		t.Helper()
+		com := &models.Comment{ThreadID: threadID, ParentID: parentID, Content: "moved"}
+		require.NoError(t, repo.Create(t.Context(), com))
+		return com
+	}
+	get := func(id int64) *models.Comment {
+		t.Helper()
+		com, err := repo.GetByID(t.Context(), id)
+		require.NoError(t, err)
+		return com
+	}
+
+	// a ── a1 ── a1x
+	// b
+	a := create(nil)
+	a1 := create(&a.ID)
+	a1x := create(&a1.ID)
+	b := create(nil)
+
+	t.Run("cycle", func(t *testing.T) {
+		err := repo.Move(t.Context(), a.ID, &a1x.ID)
+		require.ErrorIs(t, err, repository.ErrCycle)
+		err = repo.Move(t.Context(), a.ID, &a.ID)
+		require.ErrorIs(t, err, repository.ErrCycle)
+	})
+
+	t.Run("to another parent", func(t *testing.T) {
+		require.NoError(t, repo.Move(t.Context(), a1.ID, &b.ID))
+
+		require.Equal(t, b.ID, *get(a1.ID).ParentID)
+
+		moved := get(a.ID)
+		require.Zero(t, moved.ReplyCount)
+		require.Zero(t, moved.DescendantCount)
+		require.Nil(t, moved.LastReplyAt)
+
+		target := get(b.ID)
+		require.Equal(t, 1, target.ReplyCount)
+		require.Equal(t, 2, target.DescendantCount)
+		require.True(t, a1x.CreatedAt.Equal(*target.LastReplyAt))
+	})
+
+	t.Run("to root", func(t *testing.T) {
+		require.NoError(t, repo.Move(t.Context(), a1x.ID, nil))
+
+		require.Nil(t, get(a1x.ID).ParentID)
+
+		target := get(b.ID)
+		require.Equal(t, 1, target.ReplyCount)
+		require.Equal(t, 1, target.DescendantCount)
+		require.True(t, a1.CreatedAt.Equal(*target.LastReplyAt))
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		missing := int64(-1)
+		err := repo.Move(t.Context(), a.ID, &missing)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	fixed, err := repo.RepairCounts(t.Context())
+	require.NoError(t, err)
+	require.Zero(t, fixed)
+}
+
+func TestCommentsRepository_ConcurrentWrites(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	create := func(parentID *int64) *models.Comment {
+		t.Helper()
+		com := &models.Comment{ThreadID: threadID, ParentID: parentID, Content: "concurrent"}
+		require.NoError(t, repo.Create(t.Context(), com))
+		return com
+	}
+
+	// replies to the leaf lock the chain root first while moves and deletes
+	// start from the comment they change, which must not deadlock
+	// a ── a1 ── a1x
+	// b
+	a := create(nil)
+	a1 := create(&a.ID)
+	a1x := create(&a1.ID)
+	b := create(nil)
+
+	const rounds = 20
+	errs := make(chan error, 3*rounds)
+	var wg sync.WaitGroup
+	for i := range rounds {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			errs <- repo.Create(t.Context(), &models.Comment{ThreadID: threadID, ParentID: &a1x.ID, Content: "reply"})
+		}()
+		go func() {
+			defer wg.Done()
+			target := &a.ID
+			if i%2 == 0 {
+				target = &b.ID
+			}
+			errs <- repo.Move(t.Context(), a1.ID, target)
+		}()
+		go func() {
+			defer wg.Done()
+			leaf := &models.Comment{ThreadID: threadID, ParentID: &a1x.ID, Content: "short lived"}
+			if err := repo.Create(t.Context(), leaf); err != nil {
+				errs <- err
+				return
+			}
+			_, err := repo.Delete(t.Context(), leaf.ID, models.DeletePolicyCascade, nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	fixed, err := repo.RepairCounts(t.Context())
+	require.NoError(t, err)
+	require.Zero(t, fixed)
+}
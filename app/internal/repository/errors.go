@@ -15,6 +15,8 @@ var (
 	ErrInvalidID           = errors.New("invalid id")
 	ErrInvalidValue        = errors.New("invalid value")
 	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrConflict            = errors.New("version conflict")
+	ErrCycle               = errors.New("comment cannot be moved into its own subtree")
 )
 
 func wrapDBError(err error) error {
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+)
+
+// Move attaches the comment id with its whole subtree to parentID, or makes
+// it a root when parentID is nil, and moves the reply counters along in the
+// same transaction. Moving a comment below itself or one of its replies
+// fails with ErrCycle.
+func (r *CommentsRepository) Move(ctx context.Context, id int64, parentID *int64) error {
+	if id == 0 {
+		return ErrInvalidID
+	}
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		// the comment leaves one chain and joins another, both are locked
+		// at once so that the order matches every other writer
+		locked := []int64{id}
+		if parentID != nil {
+			locked = append(locked, *parentID)
+		}
+		if err := lockChains(ctx, tx, locked...); err != nil {
+			return err
+		}
+
+		var (
+			oldParentID *int64
+			path        string
+			descendants int64
+			createdAt   time.Time
+			lastReplyAt *time.Time
+		)
+		err := tx.QueryRowContext(ctx, `
+		SELECT parent_id, path, descendant_count, created_at, last_reply_at
+		FROM comments
+		WHERE id = $1`, id,
+		).Scan(&oldParentID, &path, &descendants, &createdAt, &lastReplyAt)
+		if err != nil {
+			return err
+		}
+
+		if equalIDs(oldParentID, parentID) {
+			return nil
+		}
+
+		var newChain []int64
+		if parentID != nil {
+			// the chain of the new parent runs through the comment itself
+			// exactly when the new parent lies in its subtree
+			newChain, err = ancestorChain(ctx, tx, *parentID)
+			if err != nil {
+				return err
+			}
+			if len(newChain) == 0 {
+				return ErrNotFound
+			}
+			if slices.Contains(newChain, id) {
+				return ErrCycle
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE comments SET parent_id = $2 WHERE id = $1`, id, parentID,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := graftPaths(ctx, tx, path, pathLevels(path)-1, parentID); err != nil {
+			return err
+		}
+
+		if err := removeReplies(ctx, tx, oldParentID, 1, descendants+1); err != nil {
+			return err
+		}
+
+		if parentID == nil {
+			return nil
+		}
+
+		newest := createdAt
+		if lastReplyAt != nil && lastReplyAt.After(newest) {
+			newest = *lastReplyAt
+		}
+		return countReply(ctx, tx, newChain, descendants+1, newest)
+	})
+}
+
+// GetHeight returns how many levels of replies are below a comment, 0 for a
+// comment without replies.
+func (r *CommentsRepository) GetHeight(ctx context.Context, id int64) (int64, error) {
+	if id == 0 {
+		return 0, ErrInvalidID
+	}
+
+	const sqlQuery = `
+	SELECT max(nlevel(c.path) - nlevel(s.path))::bigint
+	FROM comments s
+	JOIN comments c ON c.path <@ s.path
+	WHERE s.id = $1;
+	`
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sqlQuery, id)
+	if err != nil {
+		return 0, wrapDBError(err)
+	}
+
+	var height *int64
+	if err := row.Scan(&height); err != nil {
+		return 0, wrapDBError(err)
+	}
+	if height == nil {
+		return 0, ErrNotFound
+	}
+
+	return *height, nil
+}
+
+func equalIDs(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// pathLevels returns how many comments a path names, the comment itself
+// included.
+func pathLevels(path string) int64 {
+	return int64(strings.Count(path, ".") + 1)
+}
+
+// graftPaths rewrites the paths of the subtree at root after it changed its
+// place: the first levels labels of every path below root are replaced with
+// the path of parentID, or dropped when parentID is nil. Paths that are not
+// longer than levels are left alone.
+func graftPaths(ctx context.Context, tx *sql.Tx, root string, levels int64, parentID *int64) error {
+	_, err := tx.ExecContext(ctx, `
+	UPDATE comments
+	SET path = COALESCE((SELECT p.path FROM comments p WHERE p.id = $3), ''::ltree) || subpath(path, $2::int)
+	WHERE path <@ $1::ltree AND nlevel(path) > $2::int`,
+		root, levels, parentID,
+	)
+	return err
+}
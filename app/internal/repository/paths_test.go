@@ -0,0 +1,93 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsRepository_Paths(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	create := func(parentID *int64, content string) *models.Comment {
+		t.Helper()
+		com := &models.Comment{ThreadID: threadID, ParentID: parentID, Content: content}
+		require.NoError(t, repo.Create(t.Context(), com))
+		return com
+	}
+	path := func(id int64) string {
+		t.Helper()
+		var p string
+		err := db.QueryRowContext(t.Context(), `SELECT path::text FROM comments WHERE id = $1`, id).Scan(&p)
+		require.NoError(t, err)
+		return p
+	}
+	pathOf := func(ids ...int64) string {
+		p := fmt.Sprint(ids[0])
+		for _, id := range ids[1:] {
+			p += fmt.Sprintf(".%d", id)
+		}
+		return p
+	}
+
+	// a ── a1 ── a1x
+	// b ── b1
+	a := create(nil, "яблоко")
+	a1 := create(&a.ID, "яблоко")
+	a1x := create(&a1.ID, "яблоко")
+	b := create(nil, "груша")
+	b1 := create(&b.ID, "груша")
+
+	t.Run("create", func(t *testing.T) {
+		require.Equal(t, pathOf(a.ID), path(a.ID))
+		require.Equal(t, pathOf(a.ID, a1.ID, a1x.ID), path(a1x.ID))
+
+		height, err := repo.GetHeight(t.Context(), a.ID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, height)
+	})
+
+	t.Run("search under", func(t *testing.T) {
+		results, err := repo.Search(t.Context(), models.SearchQuery{
+			Query: "яблоко", Under: a.ID, Page: models.Page{Limit: 10},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Items, 2)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		require.NoError(t, repo.Move(t.Context(), a1.ID, &b1.ID))
+
+		require.Equal(t, pathOf(b.ID, b1.ID, a1.ID), path(a1.ID))
+		require.Equal(t, pathOf(b.ID, b1.ID, a1.ID, a1x.ID), path(a1x.ID))
+
+		ancestors, err := repo.GetAncestors(t.Context(), a1x.ID)
+		require.NoError(t, err)
+		require.Len(t, ancestors, 3)
+		require.Equal(t, b.ID, ancestors[0].ID)
+		require.Equal(t, a1.ID, ancestors[2].ID)
+	})
+
+	t.Run("reparent", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), b1.ID, models.DeletePolicyReparent, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, pathOf(b.ID, a1.ID), path(a1.ID))
+		require.Equal(t, pathOf(b.ID, a1.ID, a1x.ID), path(a1x.ID))
+	})
+
+	t.Run("to root", func(t *testing.T) {
+		require.NoError(t, repo.Move(t.Context(), a1.ID, nil))
+
+		require.Equal(t, pathOf(a1.ID), path(a1.ID))
+		require.Equal(t, pathOf(a1.ID, a1x.ID), path(a1x.ID))
+	})
+}
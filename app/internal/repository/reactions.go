@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+
+	"comment-tree/internal/models"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// AddReaction puts reaction of authorID on a comment. Adding the same reaction
+// twice is not an error. Deleted comments report ErrNotFound.
+func (r *CommentsRepository) AddReaction(ctx context.Context, commentID, authorID int64, reaction string) error {
+	if commentID == 0 || authorID == 0 {
+		return ErrInvalidID
+	}
+
+	const sqlQuery = `
+	WITH target AS (
+		SELECT id FROM comments WHERE id = $1 AND deleted_at IS NULL
+	), added AS (
+		INSERT INTO comment_reactions (comment_id, author_id, reaction)
+		SELECT id, $2, $3 FROM target
+		ON CONFLICT DO NOTHING
+	)
+	SELECT EXISTS (SELECT 1 FROM target);
+	`
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sqlQuery, commentID, authorID, reaction)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	var found bool
+	if err := row.Scan(&found); err != nil {
+		return wrapDBError(err)
+	}
+	if !found {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RemoveReaction takes back reaction of authorID. Removing a reaction that was
+// never added is not an error.
+func (r *CommentsRepository) RemoveReaction(ctx context.Context, commentID, authorID int64, reaction string) error {
+	if commentID == 0 || authorID == 0 {
+		return ErrInvalidID
+	}
+
+	query := r.sb.Delete("comment_reactions").
+		Where(squirrel.Eq{"comment_id": commentID, "author_id": authorID, "reaction": reaction})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	return wrapDBError(err)
+}
+
+// GetReactions aggregates the reactions of several comments in one query,
+// ordered by name. Mine is set for the reactions of authorID, which is nil
+// for anonymous callers. Comments without reactions are missing from the
+// result.
+func (r *CommentsRepository) GetReactions(ctx context.Context, authorID *int64, commentIDs []int64) (map[int64][]models.Reaction, error) {
+	reactions := make(map[int64][]models.Reaction)
+	if len(commentIDs) == 0 {
+		return reactions, nil
+	}
+
+	query := r.sb.
+		Select("comment_id", "reaction", "count(*)").
+		Column(squirrel.Expr("COALESCE(bool_or(author_id = ?::bigint), false)", authorID)).
+		From("comment_reactions").
+		Where(squirrel.Eq{"comment_id": commentIDs}).
+		GroupBy("comment_id", "reaction").
+		OrderBy("comment_id", "reaction")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id int64
+			re models.Reaction
+		)
+		if err := rows.Scan(&id, &re.Name, &re.Count, &re.Mine); err != nil {
+			return nil, wrapDBError(err)
+		}
+		reactions[id] = append(reactions[id], re)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,70 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsRepository_Reactions(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	authors := repository.NewAuthorsRepository(db, strategy)
+
+	alice := models.Author{DisplayName: "Alice"}
+	require.NoError(t, authors.Create(t.Context(), &alice))
+	bob := models.Author{DisplayName: "Bob"}
+	require.NoError(t, authors.Create(t.Context(), &bob))
+
+	threadID := newThread(t)
+	first := models.Comment{ThreadID: threadID, Content: "first"}
+	require.NoError(t, repo.Create(t.Context(), &first))
+	second := models.Comment{ThreadID: threadID, Content: "second"}
+	require.NoError(t, repo.Create(t.Context(), &second))
+
+	require.NoError(t, repo.AddReaction(t.Context(), first.ID, alice.ID, "heart"))
+	require.NoError(t, repo.AddReaction(t.Context(), first.ID, alice.ID, "heart"))
+	require.NoError(t, repo.AddReaction(t.Context(), first.ID, bob.ID, "heart"))
+	require.NoError(t, repo.AddReaction(t.Context(), first.ID, bob.ID, "eyes"))
+	require.NoError(t, repo.AddReaction(t.Context(), second.ID, bob.ID, "tada"))
+
+	reactions, err := repo.GetReactions(t.Context(), &alice.ID, []int64{first.ID, second.ID})
+	require.NoError(t, err)
+	require.Equal(t, map[int64][]models.Reaction{
+		first.ID: {
+			{Name: "eyes", Count: 1},
+			{Name: "heart", Count: 2, Mine: true},
+		},
+		second.ID: {
+			{Name: "tada", Count: 1},
+		},
+	}, reactions)
+
+	t.Run("anonymous", func(t *testing.T) {
+		reactions, err := repo.GetReactions(t.Context(), nil, []int64{first.ID})
+		require.NoError(t, err)
+		require.Equal(t, []models.Reaction{{Name: "eyes", Count: 1}, {Name: "heart", Count: 2}}, reactions[first.ID])
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, repo.RemoveReaction(t.Context(), first.ID, alice.ID, "heart"))
+		require.NoError(t, repo.RemoveReaction(t.Context(), first.ID, alice.ID, "heart"))
+
+		reactions, err := repo.GetReactions(t.Context(), &alice.ID, []int64{first.ID})
+		require.NoError(t, err)
+		require.Equal(t, []models.Reaction{{Name: "eyes", Count: 1}, {Name: "heart", Count: 1}}, reactions[first.ID])
+	})
+
+	t.Run("deleted comment", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), second.ID, models.DeletePolicyTombstone, nil)
+		require.NoError(t, err)
+
+		err = repo.AddReaction(t.Context(), second.ID, alice.ID, "heart")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
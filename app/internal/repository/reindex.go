@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"comment-tree/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetIndexBatch returns up to limit comments with ids above afterID in id
+// order. Only the id, content, language and search configuration are set.
+func (r *CommentsRepository) GetIndexBatch(ctx context.Context, afterID int64, limit int) ([]*models.Comment, error) {
+	const sqlQuery = `
+	SELECT id, content, search_config::text, lang
+	FROM comments
+	WHERE id > $1
+	ORDER BY id
+	LIMIT $2;
+	`
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sqlQuery, afterID, limit)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	coms := []*models.Comment{}
+	for rows.Next() {
+		com := &models.Comment{}
+		if err := rows.Scan(&com.ID, &com.Content, &com.SearchConfig, &com.Lang); err != nil {
+			return nil, wrapDBError(err)
+		}
+		coms = append(coms, com)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return coms, nil
+}
+
+// SetIndex stores the language and search configuration of coms in one
+// statement. search_vector is regenerated from search_config by Postgres.
+func (r *CommentsRepository) SetIndex(ctx context.Context, coms []*models.Comment) error {
+	if len(coms) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(coms))
+	configs := make([]string, len(coms))
+	langs := make([]string, len(coms))
+	for i, com := range coms {
+		ids[i], configs[i], langs[i] = com.ID, com.SearchConfig, com.Lang
+	}
+
+	const sqlQuery = `
+	UPDATE comments AS c
+	SET search_config = u.cfg::regconfig, lang = u.lang
+	FROM unnest($1::bigint[], $2::text[], $3::text[]) AS u(id, cfg, lang)
+	WHERE c.id = u.id;
+	`
+
+	_, err := r.db.ExecWithRetry(ctx, r.strategy, sqlQuery, pq.Array(ids), pq.Array(configs), pq.Array(langs))
+	return wrapDBError(err)
+}
@@ -2,14 +2,29 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 
 	"comment-tree/internal/models"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
 )
 
+// commentColumns is the column list every comment read selects from the
+// comments table aliased as c, in the order scanComment expects them.
+var commentColumns = []string{
+	"c.id", "c.parent_id", "c.thread_id", "c.author_id", "c.content", "c.created_at", "c.deleted_at",
+	"c.updated_at", "c.edit_count", "c.version", "c.locked_at", "c.score",
+	"c.reply_count", "c.descendant_count", "c.last_reply_at", "c.lang",
+}
+
+var commentColumnList = strings.Join(commentColumns, ", ")
+
 type CommentsRepository struct {
 	db       *dbpg.DB
 	strategy retry.Strategy
@@ -24,84 +39,422 @@ func NewCommentsRepository(db *dbpg.DB, strategy retry.Strategy) *CommentsReposi
 	}
 }
 
+// Create stores a new comment and counts it as a reply of all of its
+// ancestors in the same transaction.
 func (r *CommentsRepository) Create(ctx context.Context, com *models.Comment) error {
 	if com == nil {
 		return ErrNilValue
 	}
 
-	query := r.sb.Insert("comments").
-		Columns(
-			"parent_id", "content", "created_at",
-		).Values(
-		com.ParentID, com.Content, com.CreatedAt,
-	).Suffix("RETURNING id")
+	// created_at comes from the column default, the whole row is read back so
+	// that nothing the client sent for server managed fields survives. The id
+	// is drawn first because the path ends with it.
+	sqlQuery := `
+	WITH n AS (
+		SELECT nextval(pg_get_serial_sequence('comments', 'id')) AS id
+	)
+	INSERT INTO comments AS c (id, parent_id, thread_id, author_id, content, search_config, lang, path)
+	SELECT n.id, $1::bigint, $2::bigint, $3::bigint, $4::text, $5::regconfig, $6::text,
+		COALESCE((SELECT p.path FROM comments p WHERE p.id = $1::bigint), ''::ltree) || n.id::text
+	FROM n
+	RETURNING ` + commentColumnList
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var chain []int64
+		if com.ParentID != nil {
+			// the ancestors are locked before the parent path is read, so a
+			// concurrent move cannot leave the new comment with a stale path
+			var err error
+			chain, err = ancestorChain(ctx, tx, *com.ParentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		row := tx.QueryRowContext(ctx, sqlQuery,
+			com.ParentID, com.ThreadID, com.AuthorID, com.Content, searchConfig(com.SearchConfig), com.Lang,
+		)
+		if err := scanComment(row, com); err != nil {
+			return err
+		}
 
-	sql, args, err := query.ToSql()
+		if com.ParentID == nil {
+			return nil
+		}
+		return countReply(ctx, tx, chain, 1, com.CreatedAt)
+	})
+}
+
+// Update replaces the content of a comment and archives the replaced text as
+// a revision in the same transaction. When com.Version is set and no longer
+// matches the stored version the update fails with ErrConflict. The language
+// and the search configuration change only when com.SearchConfig is set.
+func (r *CommentsRepository) Update(ctx context.Context, com *models.Comment) error {
+	if com == nil {
+		return ErrNilValue
+	}
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var (
+			oldContent string
+			writtenAt  time.Time
+			editCount  int
+			version    int
+		)
+		err := tx.QueryRowContext(ctx, `
+		SELECT content, COALESCE(updated_at, created_at), edit_count, version
+		FROM comments
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`, com.ID,
+		).Scan(&oldContent, &writtenAt, &editCount, &version)
+		if err != nil {
+			return err
+		}
+
+		if com.Version != 0 && com.Version != version {
+			return ErrConflict
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO comment_revisions (comment_id, rev, content, created_at)
+		VALUES ($1, $2, $3, $4)`,
+			com.ID, editCount+1, oldContent, writtenAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRowContext(ctx, `
+		UPDATE comments AS c
+		SET content = $2, updated_at = now(), edit_count = c.edit_count + 1, version = c.version + 1,
+			search_config = COALESCE(NULLIF($3::text, '')::regconfig, c.search_config),
+			lang = CASE WHEN $3::text = '' THEN c.lang ELSE $4::text END
+		WHERE c.id = $1
+		RETURNING `+commentColumnList,
+			com.ID, com.Content, com.SearchConfig, com.Lang,
+		)
+		return scanComment(row, com)
+	})
+}
+
+// Delete removes a comment according to policy inside one transaction and
+// returns the number of descendants that were removed or moved. deletedBy is
+// recorded on tombstones, nil when the author is unknown.
+func (r *CommentsRepository) Delete(ctx context.Context, id int64, policy models.DeletePolicy, deletedBy *int64) (int64, error) {
+	if id == 0 {
+		return 0, ErrNilValue
+	}
+
+	var affected int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockChains(ctx, tx, id); err != nil {
+			return err
+		}
+
+		var (
+			parentID *int64
+			path     string
+		)
+		err := tx.QueryRowContext(ctx,
+			`SELECT parent_id, path FROM comments WHERE id = $1`, id,
+		).Scan(&parentID, &path)
+		if err != nil {
+			return err
+		}
+
+		switch policy {
+		case models.DeletePolicyCascade:
+			affected, err = deleteSubtree(ctx, tx, id)
+			if err == nil {
+				err = removeReplies(ctx, tx, parentID, 1, affected+1)
+			}
+		case models.DeletePolicyTombstone:
+			// the tombstone stays in the tree, so no counter changes
+			err = tombstone(ctx, tx, id, deletedBy)
+		case models.DeletePolicyReparent:
+			affected, err = reparentChildren(ctx, tx, id, parentID)
+			if err == nil {
+				err = graftPaths(ctx, tx, path, pathLevels(path), parentID)
+			}
+			if err == nil {
+				_, err = tx.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id)
+			}
+			if err == nil {
+				// the parent swaps the comment for its replies and loses only
+				// the comment itself from its subtree
+				err = removeReplies(ctx, tx, parentID, 1-affected, 1)
+			}
+		default:
+			return ErrInvalidValue
+		}
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// Purge permanently removes a comment together with all of its replies,
+// tombstones included.
+func (r *CommentsRepository) Purge(ctx context.Context, id int64) error {
+	if id == 0 {
+		return ErrNilValue
+	}
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockChains(ctx, tx, id); err != nil {
+			return err
+		}
+
+		var parentID *int64
+		err := tx.QueryRowContext(ctx,
+			`SELECT parent_id FROM comments WHERE id = $1`, id,
+		).Scan(&parentID)
+		if err != nil {
+			return err
+		}
+
+		removed, err := deleteSubtree(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		return removeReplies(ctx, tx, parentID, 1, removed+1)
+	})
+}
+
+// deleteSubtree removes the comment with all its replies and returns how many
+// replies went with it.
+func deleteSubtree(ctx context.Context, tx *sql.Tx, id int64) (int64, error) {
+	const sqlQuery = `
+	DELETE FROM comments
+	WHERE path <@ (SELECT path FROM comments WHERE id = $1);
+	`
+
+	res, err := tx.ExecContext(ctx, sqlQuery, id)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrNotFound
+	}
+
+	return n - 1, nil
+}
+
+// tombstone marks the comment deleted while the row stays so that its
+// replies keep their parent. Deleting a tombstone again reports ErrNotFound.
+func tombstone(ctx context.Context, tx *sql.Tx, id int64, deletedBy *int64) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE comments SET deleted_at = now(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`,
+		id, deletedBy,
+	)
 	if err != nil {
 		return err
 	}
 
-	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	n, err := res.RowsAffected()
 	if err != nil {
-		return wrapDBError(err)
+		return err
 	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
 
-	return wrapDBError(
-		row.Scan(&com.ID),
+// reparentChildren moves the direct replies of id under parentID, which is nil
+// when they become roots.
+func reparentChildren(ctx context.Context, tx *sql.Tx, id int64, parentID *int64) (int64, error) {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE comments SET parent_id = $2 WHERE parent_id = $1`, id, parentID,
 	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
 }
 
-func (r *CommentsRepository) Update(ctx context.Context, com *models.Comment) error {
-	if com == nil {
-		return ErrNilValue
+func (r *CommentsRepository) GetByID(ctx context.Context, id int64) (*models.Comment, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
 	}
 
-	query := r.sb.Update("comments").
-		Set("content", com.Content).
-		Where(squirrel.Eq{"id": com.ID})
+	query := r.sb.
+		Select(commentColumns...).
+		From("comments c").
+		Where(squirrel.Eq{"c.id": id})
 
 	sql, args, err := query.ToSql()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
 
-	return wrapDBError(err)
+	com := &models.Comment{}
+	if err := scanComment(row, com); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return com, nil
 }
 
-func (r *CommentsRepository) Delete(ctx context.Context, id int64) error {
+// GetAncestors returns the chain of parents of a comment starting from the
+// root. A root comment has no ancestors.
+func (r *CommentsRepository) GetAncestors(ctx context.Context, id int64) ([]*models.Comment, error) {
 	if id == 0 {
-		return ErrNilValue
+		return nil, ErrInvalidID
 	}
 
-	query := r.sb.Delete("comments").
-		Where(squirrel.Eq{"id": id})
+	sqlQuery := `
+	SELECT ` + commentColumnList + `
+	FROM comments s
+	JOIN comments c ON c.path @> s.path AND c.id <> s.id
+	WHERE s.id = $1
+	ORDER BY nlevel(c.path);
+	`
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sqlQuery, id)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	ancestors := []*models.Comment{}
+	for rows.Next() {
+		c := &models.Comment{}
+		if err := scanComment(rows, c); err != nil {
+			return nil, wrapDBError(err)
+		}
+		ancestors = append(ancestors, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return ancestors, nil
+}
+
+// SetLocked locks or unlocks a comment for new replies in its subtree.
+func (r *CommentsRepository) SetLocked(ctx context.Context, id int64, locked bool) error {
+	if id == 0 {
+		return ErrInvalidID
+	}
+
+	lockedAt := squirrel.Expr("NULL")
+	if locked {
+		lockedAt = squirrel.Expr("COALESCE(locked_at, now())")
+	}
+
+	query := r.sb.Update("comments").
+		Set("locked_at", lockedAt).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil})
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	res, err := r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
 
-	return wrapDBError(err)
+	n, err := res.RowsAffected()
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// commentSort is how a listing order maps to SQL: the sort key, its direction
+// and whether the key goes into Cursor.Rank instead of Cursor.CreatedAt. Every
+// order breaks ties by id in the same direction so keyset pagination is stable.
+type commentSort struct {
+	key  string
+	desc bool
+	rank bool
+}
+
+var commentSorts = map[models.Sort]commentSort{
+	models.SortOld:           {key: "c.created_at"},
+	models.SortNew:           {key: "c.created_at", desc: true},
+	models.SortTop:           {key: "c.score", desc: true, rank: true},
+	models.SortControversial: {key: "c.controversy", desc: true, rank: true},
+	models.SortHot:           {key: "c.hot", desc: true, rank: true},
 }
 
-func (r *CommentsRepository) GetByParent(ctx context.Context, parentID *int64, limit, offset int64) ([]*models.Comment, error) {
+// GetByParent lists comments in q.Sort order, the oldest first when no order
+// is given.
+func (r *CommentsRepository) GetByParent(ctx context.Context, q models.CommentsQuery) (*models.CommentsPage, error) {
+	page := q.Page
+
+	sortBy := q.Sort
+	if sortBy == "" {
+		sortBy = models.SortOld
+	}
+	order, ok := commentSorts[sortBy]
+	if !ok {
+		return nil, models.ErrInvalidSort
+	}
+
+	dir, cmp := "ASC", ">"
+	if order.desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	// one extra row tells whether there is a next page
 	query := r.sb.
-		Select("id", "parent_id", "content", "created_at").
-		From("comments").
-		OrderBy("created_at").
-		Limit(uint64(limit)).
-		Offset(uint64(offset))
+		Select(commentColumns...).
+		From("comments c").
+		OrderBy(order.key+" "+dir, "c.id "+dir).
+		Limit(fetchLimit(page.Limit))
+
+	if order.rank {
+		query = query.Column(order.key + "::float8")
+	}
 
-	if parentID == nil {
+	if q.ParentID == nil {
 		// parent_id IS NULL
-		query = query.Where("parent_id IS NULL")
+		query = query.Where("c.parent_id IS NULL")
 	} else {
 		// parent_id = $1
-		query = query.Where(squirrel.Eq{"parent_id": *parentID})
+		query = query.Where(squirrel.Eq{"c.parent_id": *q.ParentID})
+	}
+
+	if q.ThreadID != 0 {
+		query = query.Where(squirrel.Eq{"c.thread_id": q.ThreadID})
+	}
+
+	if page.Cursor != nil {
+		var key any = page.Cursor.CreatedAt
+		if order.key == "c.score" {
+			// compared as an integer so that the index on score is used
+			key = int64(page.Cursor.Rank)
+		} else if order.rank {
+			key = page.Cursor.Rank
+		}
+		query = query.Where("("+order.key+", c.id) "+cmp+" (?, ?)", key, page.Cursor.ID)
+	} else {
+		query = query.Offset(uint64(max(page.Offset, 0)))
 	}
 
 	sql, args, err := query.ToSql()
@@ -115,52 +468,315 @@ func (r *CommentsRepository) GetByParent(ctx context.Context, parentID *int64, l
 	}
 	defer rows.Close()
 
-	var result []*models.Comment
+	var (
+		result []*models.Comment
+		rank   float64
+		extra  []any
+	)
+	if order.rank {
+		extra = []any{&rank}
+	}
+	ranks := make(map[int64]float64)
 	for rows.Next() {
 		c := &models.Comment{}
-		if err := rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.CreatedAt); err != nil {
+		if err := scanComment(rows, c, extra...); err != nil {
 			return nil, wrapDBError(err)
 		}
+		ranks[c.ID] = rank
 		result = append(result, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
 
-	return result, nil
+	return newCommentsPage(result, page.Limit, func(last *models.Comment) models.Cursor {
+		if order.rank {
+			return models.Cursor{Rank: ranks[last.ID], ID: last.ID, Sort: sortBy}
+		}
+		return models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Sort: sortBy}
+	}), nil
 }
 
-func (r *CommentsRepository) Search(ctx context.Context, query string, limit, offset int64) ([]*models.Comment, error) {
-	if query == "" {
+// Search finds comments matching q.Query, the most relevant first, and
+// highlights the matched words in their content. Without q.Query it lists the
+// comments matching the filters, newest first and without snippets.
+func (r *CommentsRepository) Search(ctx context.Context, q models.SearchQuery) (*models.SearchPage, error) {
+	if q.Query == "" && !q.HasFilters() {
 		return nil, ErrNilValue
 	}
+	page := q.Page
+	ranked := q.Query != ""
 
-	const sqlQuery = `
-	WITH q AS (
-		SELECT websearch_to_tsquery('russian', $1) AS tsq
-	)
-	SELECT id, parent_id, content, created_at
-	FROM comments, q
-	WHERE search_vector @@ q.tsq
-	ORDER BY ts_rank(search_vector, q.tsq) DESC, created_at DESC
-	LIMIT $2 OFFSET $3;
+	langs := q.Langs
+	if len(langs) == 0 {
+		langs = []string{models.DefaultSearchConfig}
+	}
+
+	const rank = "ts_rank(c.search_vector, q.tsq)"
+
+	var found squirrel.SelectBuilder
+	if ranked {
+		found = r.sb.
+			Select("c.id", rank+" AS rank", "q.cfg", "q.tsq").
+			Prefix(`WITH q AS (
+				SELECT l.cfg, websearch_to_tsquery(l.cfg, ?) AS tsq
+				FROM unnest(?::regconfig[]) AS l(cfg)
+			)`, q.Query, pq.Array(langs)).
+			From("comments c").
+			Join("q ON c.search_vector @@ q.tsq").
+			OrderBy("rank DESC", "c.id DESC")
+
+		if len(langs) > 1 {
+			// every comment is matched against the query parsed with its own
+			// configuration
+			found = found.Where("c.search_config = q.cfg")
+		}
+		if page.Cursor != nil {
+			found = found.Where("("+rank+", c.id) < (?::real, ?)", page.Cursor.Rank, page.Cursor.ID)
+		}
+	} else {
+		found = r.sb.
+			Select("c.id", "0::real AS rank", "c.created_at").
+			From("comments c").
+			OrderBy("c.created_at DESC", "c.id DESC")
+
+		if page.Cursor != nil {
+			found = found.Where("(c.created_at, c.id) < (?, ?)", page.Cursor.CreatedAt, page.Cursor.ID)
+		}
+	}
 
+	found = searchFilters(found, q).
+		Where(squirrel.Eq{"c.deleted_at": nil}).
+		Limit(fetchLimit(page.Limit))
+	if page.Cursor == nil {
+		found = found.Offset(uint64(max(page.Offset, 0)))
+	}
+
+	// snippets are built for the returned page only, ts_headline parses the
+	// whole content and is the expensive part of a search
+	query := r.sb.
+		Select(commentColumns...).
+		Column("f.rank").
+		FromSelect(found, "f").
+		Join("comments c ON c.id = f.id")
+	if ranked {
+		query = query.
+			Column("ts_headline(f.cfg, c.content, f.tsq, ?)", headlineOptions(q.Highlight)).
+			OrderBy("f.rank DESC", "f.id DESC")
+	} else {
+		query = query.
+			Column("''").
+			OrderBy("f.created_at DESC", "f.id DESC")
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	results := []*models.SearchResult{}
+	for rows.Next() {
+		res := &models.SearchResult{Comment: &models.Comment{}}
+		if err := scanComment(rows, res.Comment, &res.Rank, &res.Highlight); err != nil {
+			return nil, wrapDBError(err)
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	result := &models.SearchPage{}
+	result.Items, result.HasMore = trimPage(results, page.Limit)
+	if result.HasMore && len(result.Items) > 0 {
+		last := result.Items[len(result.Items)-1]
+		cursor := models.Cursor{Rank: last.Rank, ID: last.Comment.ID}
+		if !ranked {
+			cursor = models.Cursor{CreatedAt: last.Comment.CreatedAt, ID: last.Comment.ID}
+		}
+		result.NextCursor = cursor.Encode()
+	}
+
+	return result, nil
+}
+
+// searchFilters narrows a search of comments c down to the filters of q.
+func searchFilters(found squirrel.SelectBuilder, q models.SearchQuery) squirrel.SelectBuilder {
+	if q.ThreadID != 0 {
+		found = found.Where(squirrel.Eq{"c.thread_id": q.ThreadID})
+	}
+	if q.AuthorID != 0 {
+		found = found.Where(squirrel.Eq{"c.author_id": q.AuthorID})
+	}
+	if !q.CreatedAfter.IsZero() {
+		found = found.Where(squirrel.Gt{"c.created_at": q.CreatedAfter})
+	}
+	if !q.CreatedBefore.IsZero() {
+		found = found.Where(squirrel.Lt{"c.created_at": q.CreatedBefore})
+	}
+	if q.Under != 0 {
+		found = found.
+			Where("c.path <@ (SELECT u.path FROM comments u WHERE u.id = ?)", q.Under).
+			Where(squirrel.NotEq{"c.id": q.Under})
+	}
+	if q.RootsOnly {
+		found = found.Where("c.parent_id IS NULL")
+	}
+	return found
+}
+
+// headlineOptions formats h as ts_headline options. Values are quoted, with
+// quotes inside them doubled, so markers may hold commas and spaces.
+func headlineOptions(h models.Highlight) string {
+	quote := func(v string) string {
+		return `"` + strings.ReplaceAll(v, `"`, `""`) + `"`
+	}
+
+	opts := []string{}
+	if h.StartSel != "" {
+		opts = append(opts, "StartSel="+quote(h.StartSel))
+	}
+	if h.StopSel != "" {
+		opts = append(opts, "StopSel="+quote(h.StopSel))
+	}
+	if h.MaxFragments > 0 {
+		opts = append(opts, "MaxFragments="+strconv.Itoa(h.MaxFragments))
+	}
+	return strings.Join(opts, ", ")
+}
+
+func (r *CommentsRepository) GetSubtree(ctx context.Context, rootID, maxDepth, perLevelLimit int64) (*models.CommentNode, error) {
+	if rootID == 0 {
+		return nil, ErrInvalidID
+	}
+
+	// every parent gets one extra child so a cut off level can be reported
+	// with a "more" marker without counting all the replies. Replies below a
+	// cut off child are still read and dropped while the tree is built.
+	sqlQuery := `
+	WITH root AS (
+		SELECT path FROM comments WHERE id = $1
+	), tree AS (
+		SELECT c.id, nlevel(c.path) - nlevel(root.path) AS depth,
+			row_number() OVER (PARTITION BY c.parent_id ORDER BY c.created_at, c.id) AS rn
+		FROM comments c, root
+		WHERE c.path <@ root.path AND nlevel(c.path) <= nlevel(root.path) + $2
+	)
+	SELECT ` + commentColumnList + `, t.depth::bigint, t.rn,
+		t.depth = $2 AND c.reply_count > 0 AS truncated
+	FROM tree t
+	JOIN comments c ON c.id = t.id
+	WHERE t.rn <= $3 + 1
+	ORDER BY t.depth, t.rn;
 	`
 
-	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sqlQuery, query, limit, offset)
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sqlQuery, rootID, maxDepth, perLevelLimit)
 	if err != nil {
 		return nil, wrapDBError(err)
 	}
 	defer rows.Close()
 
-	var results []*models.Comment
+	var root *models.CommentNode
+	nodes := make(map[int64]*models.CommentNode)
 	for rows.Next() {
-		c := &models.Comment{}
-		if err := rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.CreatedAt); err != nil {
+		n := &models.CommentNode{Children: []*models.CommentNode{}}
+		var rn int64
+		if err := scanComment(rows, &n.Comment, &n.Depth, &rn, &n.More); err != nil {
 			return nil, wrapDBError(err)
 		}
-		results = append(results, c)
+
+		if n.Depth == 0 {
+			root = n
+			nodes[n.ID] = n
+			continue
+		}
+
+		parent, ok := nodes[*n.ParentID]
+		if !ok {
+			continue
+		}
+		if rn > perLevelLimit {
+			parent.More = true
+			continue
+		}
+		parent.Children = append(parent.Children, n)
+		nodes[n.ID] = n
 	}
 	if err := rows.Err(); err != nil {
 		return nil, wrapDBError(err)
 	}
 
-	return results, nil
+	if root == nil {
+		return nil, ErrNotFound
+	}
+
+	return root, nil
+}
+
+// searchConfig returns the text search configuration name, the default one
+// when name is empty.
+func searchConfig(name string) string {
+	if name == "" {
+		return models.DefaultSearchConfig
+	}
+	return name
+}
+
+// newCommentsPage trims the extra row fetched past limit and turns the last
+// returned row into the next cursor.
+func newCommentsPage(coms []*models.Comment, limit int64, cursorOf func(*models.Comment) models.Cursor) *models.CommentsPage {
+	page := &models.CommentsPage{Items: coms}
+	if page.Items == nil {
+		page.Items = []*models.Comment{}
+	}
+
+	page.Items, page.HasMore = trimPage(page.Items, limit)
+
+	if page.HasMore && len(page.Items) > 0 {
+		page.NextCursor = cursorOf(page.Items[len(page.Items)-1]).Encode()
+	}
+
+	return page
+}
+
+// fetchLimit is how many rows a page of limit items reads: one more than
+// limit, to learn whether there is a next page.
+func fetchLimit(limit int64) uint64 {
+	if limit < 0 {
+		limit = 0
+	}
+	return uint64(limit) + 1
+}
+
+// trimPage drops the extra row fetched past limit and reports whether there
+// was one.
+func trimPage[T any](items []T, limit int64) ([]T, bool) {
+	if limit < 0 {
+		limit = 0
+	}
+	if int64(len(items)) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanComment reads the commentColumns into com followed by any extra
+// columns the query selects after them.
+func scanComment(row rowScanner, com *models.Comment, extra ...any) error {
+	dest := []any{
+		&com.ID, &com.ParentID, &com.ThreadID, &com.AuthorID, &com.Content, &com.CreatedAt, &com.DeletedAt,
+		&com.UpdatedAt, &com.EditCount, &com.Version, &com.LockedAt, &com.Score,
+		&com.ReplyCount, &com.DescendantCount, &com.LastReplyAt, &com.Lang,
+	}
+	return row.Scan(append(dest, extra...)...)
 }
@@ -73,11 +73,12 @@ func TestMain(m *testing.M) {
 
 func TestCommentsRepository_CUD(t *testing.T) {
 	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
 
 	com := models.Comment{
-		Content:   "Test Content",
-		CreatedAt: time.Now().UTC(),
-		ParentID:  nil,
+		ThreadID: threadID,
+		Content:  "Test Content",
+		ParentID: nil,
 	}
 
 	t.Run("Create", func(t *testing.T) {
@@ -93,64 +94,110 @@ func TestCommentsRepository_CUD(t *testing.T) {
 	})
 
 	t.Run("Delete", func(t *testing.T) {
-		err := repo.Delete(t.Context(), com.ID)
+		_, err := repo.Delete(t.Context(), com.ID, models.DeletePolicyCascade, nil)
 		require.NoError(t, err)
 	})
 }
 
+func TestCommentsRepository_ServerTimestamps(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+
+	backdated := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	edited := backdated.Add(time.Hour)
+
+	com := models.Comment{
+		ThreadID:  newThread(t),
+		Content:   "from the past",
+		CreatedAt: backdated,
+		UpdatedAt: &edited,
+	}
+
+	before := time.Now()
+	require.NoError(t, repo.Create(t.Context(), &com))
+	require.WithinDuration(t, before, com.CreatedAt, time.Minute)
+	require.Nil(t, com.UpdatedAt)
+
+	com.CreatedAt = backdated
+	require.NoError(t, repo.Update(t.Context(), &com))
+	require.WithinDuration(t, before, com.CreatedAt, time.Minute)
+	require.NotNil(t, com.UpdatedAt)
+	require.False(t, com.UpdatedAt.Before(com.CreatedAt))
+}
+
 func TestCommentsRepository_Get(t *testing.T) {
 	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
 
 	rootCom := models.Comment{
-		Content:   "Test Content",
-		CreatedAt: time.Now().UTC(),
-		ParentID:  nil,
+		ThreadID: threadID,
+		Content:  "Test Content",
+		ParentID: nil,
 	}
 	err := repo.Create(t.Context(), &rootCom)
 	require.NoError(t, err)
 
 	rootComChildren1 := models.Comment{
-		Content:   "Test Content",
-		CreatedAt: time.Now().UTC(),
-		ParentID:  &rootCom.ID,
+		ThreadID: threadID,
+		Content:  "Test Content",
+		ParentID: &rootCom.ID,
 	}
 
 	err = repo.Create(t.Context(), &rootComChildren1)
 	require.NoError(t, err)
 
 	rootComChildren2 := models.Comment{
-		Content:   "Test Content",
-		CreatedAt: time.Now().UTC(),
-		ParentID:  &rootCom.ID,
+		ThreadID: threadID,
+		Content:  "Test Content",
+		ParentID: &rootCom.ID,
 	}
 
 	err = repo.Create(t.Context(), &rootComChildren2)
 	require.NoError(t, err)
 
-	coms, err := repo.GetByParent(t.Context(), nil, 10, 0)
+	coms, err := repo.GetByParent(t.Context(), models.CommentsQuery{ThreadID: threadID, Page: models.Page{Limit: 10}})
 	expectedRootCom := []*models.Comment{&rootCom}
 
 	require.NoError(t, err)
-	require.Len(t, coms, len(expectedRootCom))
+	require.Len(t, coms.Items, len(expectedRootCom))
 
-	chlComs, err := repo.GetByParent(t.Context(), &rootCom.ID, 10, 0)
+	chlComs, err := repo.GetByParent(t.Context(), models.CommentsQuery{ParentID: &rootCom.ID, Page: models.Page{Limit: 10}})
 
 	expectedChlComs := []*models.Comment{&rootComChildren1, &rootComChildren2}
 
 	require.NoError(t, err)
-	require.Len(t, chlComs, len(expectedChlComs))
+	require.Len(t, chlComs.Items, len(expectedChlComs))
+	require.False(t, chlComs.HasMore)
+
+	t.Run("cursor", func(t *testing.T) {
+		first, err := repo.GetByParent(t.Context(), models.CommentsQuery{ParentID: &rootCom.ID, Page: models.Page{Limit: 1}})
+		require.NoError(t, err)
+		require.Len(t, first.Items, 1)
+		require.True(t, first.HasMore)
+		require.NotEmpty(t, first.NextCursor)
+
+		cursor, err := models.DecodeCursor(first.NextCursor)
+		require.NoError(t, err)
+
+		second, err := repo.GetByParent(t.Context(), models.CommentsQuery{ParentID: &rootCom.ID, Page: models.Page{Limit: 1, Cursor: cursor}})
+		require.NoError(t, err)
+		require.Len(t, second.Items, 1)
+		require.False(t, second.HasMore)
+		require.Empty(t, second.NextCursor)
+		require.NotEqual(t, first.Items[0].ID, second.Items[0].ID)
+	})
 }
 
 func TestCommentsRepository_Search(t *testing.T) {
 	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
 	ctx := context.Background()
 
 	_, _ = db.ExecContext(t.Context(), "TRUNCATE comments RESTART IDENTITY CASCADE")
 
 	comments := []models.Comment{
-		{Content: "Гитарист играет аккорды", CreatedAt: time.Now()},
-		{Content: "Пианист играет мелодию", CreatedAt: time.Now().Add(1 * time.Minute)},
-		{Content: "Вокалист поёт песню", CreatedAt: time.Now().Add(2 * time.Minute)},
+		{ThreadID: threadID, Content: "Гитарист играет аккорды"},
+		{ThreadID: threadID, Content: "Пианист играет мелодию"},
+		{ThreadID: threadID, Content: "Вокалист поёт песню"},
 	}
 
 	for i := range comments {
@@ -158,26 +205,343 @@ func TestCommentsRepository_Search(t *testing.T) {
 	}
 
 	t.Run("search single word", func(t *testing.T) {
-		results, err := repo.Search(ctx, "гитарист", 10, 0)
+		results, err := repo.Search(ctx, models.SearchQuery{Query: "гитарист", Page: models.Page{Limit: 10}})
 		require.NoError(t, err)
-		require.Len(t, results, 1)
-		require.Contains(t, results[0].Content, "Гитарист")
+		require.Len(t, results.Items, 1)
+		require.Contains(t, results.Items[0].Comment.Content, "Гитарист")
+		require.Positive(t, results.Items[0].Rank)
 	})
 
 	t.Run("search multiple words", func(t *testing.T) {
-		results, err := repo.Search(ctx, "играет", 10, 0)
+		results, err := repo.Search(ctx, models.SearchQuery{Query: "играет", Page: models.Page{Limit: 10}})
 		require.NoError(t, err)
-		require.Len(t, results, 2) // "Гитарист играет аккорды" и "Пианист играет мелодию"
+		require.Len(t, results.Items, 2) // "Гитарист играет аккорды" и "Пианист играет мелодию"
 	})
 
 	t.Run("search with pagination", func(t *testing.T) {
-		results, err := repo.Search(ctx, "играет", 1, 0)
+		results, err := repo.Search(ctx, models.SearchQuery{Query: "играет", Page: models.Page{Limit: 1}})
+		require.NoError(t, err)
+		require.Len(t, results.Items, 1)
+
+		resultsNext, err := repo.Search(ctx, models.SearchQuery{Query: "играет", Page: models.Page{Limit: 1, Offset: 1}})
+		require.NoError(t, err)
+		require.Len(t, resultsNext.Items, 1)
+		require.NotEqual(t, results.Items[0].Comment.ID, resultsNext.Items[0].Comment.ID)
+	})
+
+	t.Run("search in other thread", func(t *testing.T) {
+		results, err := repo.Search(ctx, models.SearchQuery{Query: "играет", ThreadID: newThread(t), Page: models.Page{Limit: 10}})
+		require.NoError(t, err)
+		require.Empty(t, results.Items)
+	})
+
+	t.Run("search with cursor", func(t *testing.T) {
+		results, err := repo.Search(ctx, models.SearchQuery{Query: "играет", Page: models.Page{Limit: 1}})
+		require.NoError(t, err)
+		require.True(t, results.HasMore)
+
+		cursor, err := models.DecodeCursor(results.NextCursor)
+		require.NoError(t, err)
+
+		resultsNext, err := repo.Search(ctx, models.SearchQuery{Query: "играет", Page: models.Page{Limit: 1, Cursor: cursor}})
+		require.NoError(t, err)
+		require.Len(t, resultsNext.Items, 1)
+		require.False(t, resultsNext.HasMore)
+		require.NotEqual(t, results.Items[0].Comment.ID, resultsNext.Items[0].Comment.ID)
+	})
+
+	t.Run("highlight", func(t *testing.T) {
+		results, err := repo.Search(ctx, models.SearchQuery{
+			Query:     "мелодию",
+			Highlight: models.Highlight{StartSel: "[", StopSel: "]"},
+			Page:      models.Page{Limit: 10},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Items, 1)
+		require.Equal(t, "Пианист играет [мелодию]", results.Items[0].Highlight)
+	})
+
+	t.Run("search in several languages", func(t *testing.T) {
+		com := &models.Comment{ThreadID: threadID, Content: "Drummers were playing all night", Lang: "en", SearchConfig: "english"}
+		require.NoError(t, repo.Create(ctx, com))
+		require.Equal(t, "en", com.Lang)
+
+		results, err := repo.Search(ctx, models.SearchQuery{
+			Query: "played", Langs: []string{"russian", "english"}, Page: models.Page{Limit: 10},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Items, 1)
+		require.Equal(t, com.ID, results.Items[0].Comment.ID)
+	})
+}
+
+func TestCommentsRepository_GetSubtree(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	root := models.Comment{ThreadID: threadID, Content: "root"}
+	require.NoError(t, repo.Create(t.Context(), &root))
+
+	var children []models.Comment
+	for i := 0; i < 3; i++ {
+		child := models.Comment{
+			ThreadID: threadID,
+			Content:  "child",
+			ParentID: &root.ID,
+		}
+		require.NoError(t, repo.Create(t.Context(), &child))
+		children = append(children, child)
+	}
+
+	grandChild := models.Comment{
+		ThreadID: threadID,
+		Content:  "grand child",
+		ParentID: &children[0].ID,
+	}
+	require.NoError(t, repo.Create(t.Context(), &grandChild))
+
+	t.Run("whole subtree", func(t *testing.T) {
+		tree, err := repo.GetSubtree(t.Context(), root.ID, 5, 10)
+		require.NoError(t, err)
+		require.Equal(t, root.ID, tree.ID)
+		require.False(t, tree.More)
+		require.Len(t, tree.Children, 3)
+		require.Len(t, tree.Children[0].Children, 1)
+		require.Equal(t, int64(2), tree.Children[0].Children[0].Depth)
+	})
+
+	t.Run("per level limit", func(t *testing.T) {
+		tree, err := repo.GetSubtree(t.Context(), root.ID, 5, 2)
+		require.NoError(t, err)
+		require.True(t, tree.More)
+		require.Len(t, tree.Children, 2)
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		tree, err := repo.GetSubtree(t.Context(), root.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, tree.Children, 3)
+		require.True(t, tree.Children[0].More)
+		require.Empty(t, tree.Children[0].Children)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := repo.GetSubtree(t.Context(), -1, 1, 10)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsRepository_SoftDelete(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	parent := models.Comment{ThreadID: threadID, Content: "bad post"}
+	require.NoError(t, repo.Create(t.Context(), &parent))
+
+	reply := models.Comment{ThreadID: threadID, Content: "reply", ParentID: &parent.ID}
+	require.NoError(t, repo.Create(t.Context(), &reply))
+
+	_, err := repo.Delete(t.Context(), parent.ID, models.DeletePolicyTombstone, nil)
+	require.NoError(t, err)
+
+	t.Run("tombstone keeps replies", func(t *testing.T) {
+		tree, err := repo.GetSubtree(t.Context(), parent.ID, 1, 10)
+		require.NoError(t, err)
+		require.NotNil(t, tree.DeletedAt)
+		require.Len(t, tree.Children, 1)
+		require.Nil(t, tree.Children[0].DeletedAt)
+
+		replies, err := repo.GetByParent(t.Context(), models.CommentsQuery{ParentID: &parent.ID, Page: models.Page{Limit: 10}})
+		require.NoError(t, err)
+		require.Len(t, replies.Items, 1)
+	})
+
+	t.Run("purge removes subtree", func(t *testing.T) {
+		require.NoError(t, repo.Purge(t.Context(), parent.ID))
+
+		_, err := repo.GetSubtree(t.Context(), parent.ID, 1, 10)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = repo.GetSubtree(t.Context(), reply.ID, 1, 10)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsRepository_DeletePolicies(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	// root -> mid -> (leaf1, leaf2)
+	newBranch := func(t *testing.T) (root, mid models.Comment) {
+		root = models.Comment{ThreadID: threadID, Content: "root"}
+		require.NoError(t, repo.Create(t.Context(), &root))
+
+		mid = models.Comment{ThreadID: threadID, Content: "mid", ParentID: &root.ID}
+		require.NoError(t, repo.Create(t.Context(), &mid))
+
+		for i := 0; i < 2; i++ {
+			leaf := models.Comment{ThreadID: threadID, Content: "leaf", ParentID: &mid.ID}
+			require.NoError(t, repo.Create(t.Context(), &leaf))
+		}
+		return root, mid
+	}
+
+	t.Run("cascade", func(t *testing.T) {
+		root, mid := newBranch(t)
+
+		affected, err := repo.Delete(t.Context(), mid.ID, models.DeletePolicyCascade, nil)
 		require.NoError(t, err)
-		require.Len(t, results, 1)
+		require.Equal(t, int64(2), affected)
+
+		tree, err := repo.GetSubtree(t.Context(), root.ID, 5, 10)
+		require.NoError(t, err)
+		require.Empty(t, tree.Children)
+	})
+
+	t.Run("tombstone", func(t *testing.T) {
+		root, mid := newBranch(t)
+
+		affected, err := repo.Delete(t.Context(), mid.ID, models.DeletePolicyTombstone, nil)
+		require.NoError(t, err)
+		require.Zero(t, affected)
+
+		tree, err := repo.GetSubtree(t.Context(), root.ID, 5, 10)
+		require.NoError(t, err)
+		require.Len(t, tree.Children, 1)
+		require.NotNil(t, tree.Children[0].DeletedAt)
+		require.Len(t, tree.Children[0].Children, 2)
+	})
+
+	t.Run("reparent", func(t *testing.T) {
+		root, mid := newBranch(t)
+
+		affected, err := repo.Delete(t.Context(), mid.ID, models.DeletePolicyReparent, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), affected)
+
+		tree, err := repo.GetSubtree(t.Context(), root.ID, 5, 10)
+		require.NoError(t, err)
+		require.Len(t, tree.Children, 2)
+		for _, child := range tree.Children {
+			require.Equal(t, root.ID, *child.ParentID)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), -1, models.DeletePolicyCascade, nil)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsRepository_Revisions(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	com := models.Comment{ThreadID: threadID, Content: "original"}
+	require.NoError(t, repo.Create(t.Context(), &com))
+
+	for _, content := range []string{"first edit", "second edit"} {
+		com.Content = content
+		require.NoError(t, repo.Update(t.Context(), &com))
+	}
+
+	require.Equal(t, 2, com.EditCount)
+	require.NotNil(t, com.UpdatedAt)
+
+	revs, err := repo.GetRevisions(t.Context(), com.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+	require.Equal(t, "original", revs[0].Content)
+	require.Equal(t, "first edit", revs[1].Content)
 
-		resultsNext, err := repo.Search(ctx, "играет", 1, 1)
+	rev, err := repo.GetRevision(t.Context(), com.ID, 2)
+	require.NoError(t, err)
+	require.Equal(t, "first edit", rev.Content)
+
+	_, err = repo.GetRevision(t.Context(), com.ID, 3)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestCommentsRepository_UpdateVersion(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	com := models.Comment{ThreadID: threadID, Content: "original"}
+	require.NoError(t, repo.Create(t.Context(), &com))
+	require.Equal(t, 1, com.Version)
+
+	first := models.Comment{ID: com.ID, Content: "first moderator", Version: 1}
+	require.NoError(t, repo.Update(t.Context(), &first))
+	require.Equal(t, 2, first.Version)
+
+	second := models.Comment{ID: com.ID, Content: "second moderator", Version: 1}
+	err := repo.Update(t.Context(), &second)
+	require.ErrorIs(t, err, repository.ErrConflict)
+
+	unconditional := models.Comment{ID: com.ID, Content: "no precondition"}
+	require.NoError(t, repo.Update(t.Context(), &unconditional))
+	require.Equal(t, 3, unconditional.Version)
+}
+
+func TestCommentsRepository_NotFound(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	t.Run("update missing comment", func(t *testing.T) {
+		err := repo.Update(t.Context(), &models.Comment{ID: -1, Content: "nobody"})
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("delete twice", func(t *testing.T) {
+		com := models.Comment{ThreadID: threadID, Content: "once"}
+		require.NoError(t, repo.Create(t.Context(), &com))
+
+		_, err := repo.Delete(t.Context(), com.ID, models.DeletePolicyTombstone, nil)
 		require.NoError(t, err)
-		require.Len(t, resultsNext, 1)
-		require.NotEqual(t, results[0].ID, resultsNext[0].ID)
+
+		_, err = repo.Delete(t.Context(), com.ID, models.DeletePolicyTombstone, nil)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		err = repo.Update(t.Context(), &models.Comment{ID: com.ID, Content: "edit a tombstone"})
+		require.ErrorIs(t, err, repository.ErrNotFound)
 	})
 }
+
+func TestCommentsRepository_Ancestors(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	root := models.Comment{ThreadID: threadID, Content: "root"}
+	require.NoError(t, repo.Create(t.Context(), &root))
+
+	mid := models.Comment{ThreadID: threadID, Content: "mid", ParentID: &root.ID}
+	require.NoError(t, repo.Create(t.Context(), &mid))
+
+	leaf := models.Comment{ThreadID: threadID, Content: "leaf", ParentID: &mid.ID}
+	require.NoError(t, repo.Create(t.Context(), &leaf))
+
+	ancestors, err := repo.GetAncestors(t.Context(), leaf.ID)
+	require.NoError(t, err)
+	require.Len(t, ancestors, 2)
+	require.Equal(t, root.ID, ancestors[0].ID)
+	require.Equal(t, mid.ID, ancestors[1].ID)
+
+	ancestors, err = repo.GetAncestors(t.Context(), root.ID)
+	require.NoError(t, err)
+	require.Empty(t, ancestors)
+
+	require.NoError(t, repo.SetLocked(t.Context(), root.ID, true))
+
+	locked, err := repo.GetByID(t.Context(), root.ID)
+	require.NoError(t, err)
+	require.NotNil(t, locked.LockedAt)
+
+	require.NoError(t, repo.SetLocked(t.Context(), root.ID, false))
+
+	unlocked, err := repo.GetByID(t.Context(), root.ID)
+	require.NoError(t, err)
+	require.Nil(t, unlocked.LockedAt)
+
+	_, err = repo.GetByID(t.Context(), -1)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
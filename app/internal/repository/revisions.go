@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"comment-tree/internal/models"
+)
+
+func (r *CommentsRepository) GetRevisions(ctx context.Context, commentID int64) ([]*models.Revision, error) {
+	if commentID == 0 {
+		return nil, ErrInvalidID
+	}
+
+	const sqlQuery = `
+	SELECT comment_id, rev, content, created_at, replaced_at
+	FROM comment_revisions
+	WHERE comment_id = $1
+	ORDER BY rev;
+	`
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sqlQuery, commentID)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	revs := []*models.Revision{}
+	for rows.Next() {
+		rev := &models.Revision{}
+		if err := rows.Scan(&rev.CommentID, &rev.Rev, &rev.Content, &rev.CreatedAt, &rev.ReplacedAt); err != nil {
+			return nil, wrapDBError(err)
+		}
+		revs = append(revs, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return revs, nil
+}
+
+func (r *CommentsRepository) GetRevision(ctx context.Context, commentID int64, rev int) (*models.Revision, error) {
+	if commentID == 0 {
+		return nil, ErrInvalidID
+	}
+
+	const sqlQuery = `
+	SELECT comment_id, rev, content, created_at, replaced_at
+	FROM comment_revisions
+	WHERE comment_id = $1 AND rev = $2;
+	`
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sqlQuery, commentID, rev)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	res := &models.Revision{}
+	if err := row.Scan(&res.CommentID, &res.Rev, &res.Content, &res.CreatedAt, &res.ReplacedAt); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return res, nil
+}
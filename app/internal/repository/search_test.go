@@ -0,0 +1,130 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsRepository_SearchFilters(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	authors := repository.NewAuthorsRepository(db, strategy)
+	threadID := newThread(t)
+
+	alice := models.Author{DisplayName: "Alice"}
+	require.NoError(t, authors.Create(t.Context(), &alice))
+
+	// root ── reply (alice) ── nested
+	root := &models.Comment{ThreadID: threadID, Content: "Барабанщик задаёт ритм"}
+	require.NoError(t, repo.Create(t.Context(), root))
+	reply := &models.Comment{ThreadID: threadID, ParentID: &root.ID, AuthorID: &alice.ID, Content: "Ритм слишком быстрый"}
+	require.NoError(t, repo.Create(t.Context(), reply))
+	nested := &models.Comment{ThreadID: threadID, ParentID: &reply.ID, Content: "Ритм в самый раз"}
+	require.NoError(t, repo.Create(t.Context(), nested))
+
+	search := func(q models.SearchQuery) []int64 {
+		t.Helper()
+		q.Query = "ритм"
+		q.ThreadID = threadID
+		q.Page = models.Page{Limit: 10}
+
+		results, err := repo.Search(t.Context(), q)
+		require.NoError(t, err)
+
+		ids := []int64{}
+		for _, res := range results.Items {
+			ids = append(ids, res.Comment.ID)
+		}
+		return ids
+	}
+
+	t.Run("thread", func(t *testing.T) {
+		require.Len(t, search(models.SearchQuery{}), 3)
+	})
+
+	t.Run("author", func(t *testing.T) {
+		require.Equal(t, []int64{reply.ID}, search(models.SearchQuery{AuthorID: alice.ID}))
+	})
+
+	t.Run("under", func(t *testing.T) {
+		require.ElementsMatch(t, []int64{reply.ID, nested.ID}, search(models.SearchQuery{Under: root.ID}))
+		require.Equal(t, []int64{nested.ID}, search(models.SearchQuery{Under: reply.ID}))
+	})
+
+	t.Run("roots only", func(t *testing.T) {
+		require.Equal(t, []int64{root.ID}, search(models.SearchQuery{RootsOnly: true}))
+	})
+
+	t.Run("filters only", func(t *testing.T) {
+		first, err := repo.Search(t.Context(), models.SearchQuery{
+			ThreadID: threadID, Page: models.Page{Limit: 2},
+		})
+		require.NoError(t, err)
+		require.Len(t, first.Items, 2)
+		require.Equal(t, nested.ID, first.Items[0].Comment.ID)
+		require.Equal(t, reply.ID, first.Items[1].Comment.ID)
+		require.Empty(t, first.Items[0].Highlight)
+		require.True(t, first.HasMore)
+
+		cursor, err := models.DecodeCursor(first.NextCursor)
+		require.NoError(t, err)
+		rest, err := repo.Search(t.Context(), models.SearchQuery{
+			ThreadID: threadID, Page: models.Page{Limit: 2, Cursor: cursor},
+		})
+		require.NoError(t, err)
+		require.Len(t, rest.Items, 1)
+		require.Equal(t, root.ID, rest.Items[0].Comment.ID)
+		require.False(t, rest.HasMore)
+	})
+
+	t.Run("nothing to search", func(t *testing.T) {
+		_, err := repo.Search(t.Context(), models.SearchQuery{Page: models.Page{Limit: 10}})
+		require.ErrorIs(t, err, repository.ErrNilValue)
+	})
+
+	t.Run("created range", func(t *testing.T) {
+		require.Empty(t, search(models.SearchQuery{CreatedBefore: root.CreatedAt}))
+		require.Len(t, search(models.SearchQuery{CreatedAfter: root.CreatedAt.Add(-time.Second)}), 3)
+		require.Empty(t, search(models.SearchQuery{CreatedAfter: time.Now().Add(time.Hour)}))
+	})
+}
+
+func TestCommentsRepository_Reindex(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	threadID := newThread(t)
+
+	com := &models.Comment{ThreadID: threadID, Content: "Trumpeters were playing at dawn"}
+	require.NoError(t, repo.Create(t.Context(), com))
+
+	search := func() int {
+		t.Helper()
+		results, err := repo.Search(t.Context(), models.SearchQuery{
+			Query: "trumpeter", ThreadID: threadID, Langs: []string{"english"}, Page: models.Page{Limit: 10},
+		})
+		require.NoError(t, err)
+		return len(results.Items)
+	}
+
+	coms, err := repo.GetIndexBatch(t.Context(), com.ID-1, 1)
+	require.NoError(t, err)
+	require.Len(t, coms, 1)
+	require.Equal(t, com.ID, coms[0].ID)
+	require.Equal(t, models.DefaultSearchConfig, coms[0].SearchConfig)
+	require.Zero(t, search())
+
+	coms[0].SearchConfig, coms[0].Lang = "english", "en"
+	require.NoError(t, repo.SetIndex(t.Context(), coms))
+
+	coms, err = repo.GetIndexBatch(t.Context(), com.ID-1, 1)
+	require.NoError(t, err)
+	require.Equal(t, "english", coms[0].SearchConfig)
+	require.Equal(t, "en", coms[0].Lang)
+	require.Equal(t, 1, search())
+}
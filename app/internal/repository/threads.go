@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"comment-tree/internal/models"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+)
+
+var threadColumns = []string{
+	"id", "key", "title", "metadata", "locked_at", "created_at", "updated_at",
+}
+
+type ThreadsRepository struct {
+	db       *dbpg.DB
+	strategy retry.Strategy
+	sb       squirrel.StatementBuilderType
+}
+
+func NewThreadsRepository(db *dbpg.DB, strategy retry.Strategy) *ThreadsRepository {
+	return &ThreadsRepository{
+		db:       db,
+		strategy: strategy,
+		sb:       squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *ThreadsRepository) Create(ctx context.Context, th *models.Thread) error {
+	if th == nil {
+		return ErrNilValue
+	}
+
+	metadata, err := marshalMetadata(th.Metadata)
+	if err != nil {
+		return err
+	}
+
+	query := r.sb.Insert("threads").
+		Columns("key", "title", "metadata").
+		Values(th.Key, th.Title, metadata).
+		Suffix("RETURNING id, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return wrapDBError(
+		row.Scan(&th.ID, &th.CreatedAt),
+	)
+}
+
+// Update replaces the key, title and metadata of a thread.
+func (r *ThreadsRepository) Update(ctx context.Context, th *models.Thread) error {
+	if th == nil {
+		return ErrNilValue
+	}
+
+	metadata, err := marshalMetadata(th.Metadata)
+	if err != nil {
+		return err
+	}
+
+	query := r.sb.Update("threads").
+		Set("key", th.Key).
+		Set("title", th.Title).
+		Set("metadata", metadata).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": th.ID}).
+		Suffix("RETURNING locked_at, created_at, updated_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return wrapDBError(
+		row.Scan(&th.LockedAt, &th.CreatedAt, &th.UpdatedAt),
+	)
+}
+
+// Delete removes a thread together with all of its comments.
+func (r *ThreadsRepository) Delete(ctx context.Context, id int64) error {
+	if id == 0 {
+		return ErrInvalidID
+	}
+
+	sql, args, err := r.sb.Delete("threads").Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ThreadsRepository) GetByID(ctx context.Context, id int64) (*models.Thread, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+
+	return r.getOne(ctx, squirrel.Eq{"id": id})
+}
+
+// GetByKey finds a thread by its already normalized external key.
+func (r *ThreadsRepository) GetByKey(ctx context.Context, key string) (*models.Thread, error) {
+	if key == "" {
+		return nil, ErrNilValue
+	}
+
+	return r.getOne(ctx, squirrel.Eq{"key": key})
+}
+
+func (r *ThreadsRepository) getOne(ctx context.Context, where squirrel.Eq) (*models.Thread, error) {
+	sql, args, err := r.sb.Select(threadColumns...).From("threads").Where(where).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := r.db.QueryRowWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	th := &models.Thread{}
+	if err := scanThread(row, th); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return th, nil
+}
+
+// List returns threads in creation order.
+func (r *ThreadsRepository) List(ctx context.Context, page models.Page) (*models.ThreadsPage, error) {
+	query := r.sb.
+		Select(threadColumns...).
+		From("threads").
+		OrderBy("id").
+		Limit(fetchLimit(page.Limit))
+
+	if page.Cursor != nil {
+		query = query.Where(squirrel.Gt{"id": page.Cursor.ID})
+	} else {
+		query = query.Offset(uint64(max(page.Offset, 0)))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	res := &models.ThreadsPage{Items: []*models.Thread{}}
+	for rows.Next() {
+		th := &models.Thread{}
+		if err := scanThread(rows, th); err != nil {
+			return nil, wrapDBError(err)
+		}
+		res.Items = append(res.Items, th)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	res.Items, res.HasMore = trimPage(res.Items, page.Limit)
+	if res.HasMore && len(res.Items) > 0 {
+		res.NextCursor = models.Cursor{ID: res.Items[len(res.Items)-1].ID}.Encode()
+	}
+
+	return res, nil
+}
+
+// SetLocked closes a thread for new comments or opens it again.
+func (r *ThreadsRepository) SetLocked(ctx context.Context, id int64, locked bool) error {
+	if id == 0 {
+		return ErrInvalidID
+	}
+
+	lockedAt := squirrel.Expr("NULL")
+	if locked {
+		lockedAt = squirrel.Expr("COALESCE(locked_at, now())")
+	}
+
+	sql, args, err := r.sb.Update("threads").
+		Set("locked_at", lockedAt).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func marshalMetadata(metadata map[string]any) ([]byte, error) {
+	if metadata == nil {
+		return []byte("{}"), nil
+	}
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+
+	return b, nil
+}
+
+func scanThread(row rowScanner, th *models.Thread) error {
+	var metadata []byte
+	err := row.Scan(
+		&th.ID, &th.Key, &th.Title, &metadata, &th.LockedAt, &th.CreatedAt, &th.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(metadata, &th.Metadata)
+}
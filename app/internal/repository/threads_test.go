@@ -0,0 +1,100 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newThread creates a thread of its own for a test so that root listings do
+// not see the comments of other tests.
+func newThread(t *testing.T) int64 {
+	t.Helper()
+
+	th := models.Thread{Key: "test:" + t.Name() + ":" + time.Now().Format(time.RFC3339Nano)}
+	require.NoError(t, repository.NewThreadsRepository(db, strategy).Create(t.Context(), &th))
+
+	return th.ID
+}
+
+func TestThreadsRepository_CRUD(t *testing.T) {
+	repo := repository.NewThreadsRepository(db, strategy)
+
+	th := models.Thread{
+		Key:      "article:123",
+		Title:    "Article",
+		Metadata: map[string]any{"author": "editor"},
+	}
+	require.NoError(t, repo.Create(t.Context(), &th))
+	require.NotZero(t, th.ID)
+
+	t.Run("duplicate key", func(t *testing.T) {
+		err := repo.Create(t.Context(), &models.Thread{Key: th.Key})
+		require.ErrorIs(t, err, repository.ErrDuplicate)
+	})
+
+	t.Run("get by key", func(t *testing.T) {
+		got, err := repo.GetByKey(t.Context(), th.Key)
+		require.NoError(t, err)
+		require.Equal(t, th.ID, got.ID)
+		require.Equal(t, "editor", got.Metadata["author"])
+	})
+
+	t.Run("update", func(t *testing.T) {
+		th.Title = "Renamed"
+		require.NoError(t, repo.Update(t.Context(), &th))
+		require.NotNil(t, th.UpdatedAt)
+
+		got, err := repo.GetByID(t.Context(), th.ID)
+		require.NoError(t, err)
+		require.Equal(t, "Renamed", got.Title)
+	})
+
+	t.Run("lock", func(t *testing.T) {
+		require.NoError(t, repo.SetLocked(t.Context(), th.ID, true))
+
+		got, err := repo.GetByID(t.Context(), th.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.LockedAt)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		first, err := repo.List(t.Context(), models.Page{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, first.Items, 1)
+		require.True(t, first.HasMore)
+
+		cursor, err := models.DecodeCursor(first.NextCursor)
+		require.NoError(t, err)
+
+		next, err := repo.List(t.Context(), models.Page{Limit: 1, Cursor: cursor})
+		require.NoError(t, err)
+		require.Len(t, next.Items, 1)
+		require.Greater(t, next.Items[0].ID, first.Items[0].ID)
+	})
+
+	t.Run("delete removes comments", func(t *testing.T) {
+		comments := repository.NewCommentsRepository(db, strategy)
+
+		root := models.Comment{ThreadID: th.ID, Content: "root"}
+		require.NoError(t, comments.Create(t.Context(), &root))
+
+		reply := models.Comment{ThreadID: th.ID, Content: "reply", ParentID: &root.ID}
+		require.NoError(t, comments.Create(t.Context(), &reply))
+
+		require.NoError(t, repo.Delete(t.Context(), th.ID))
+
+		_, err := comments.GetByID(t.Context(), reply.ID)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		err = repo.Delete(t.Context(), th.ID)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/wb-go/wbf/dbpg"
+)
+
+// inTx runs fn in a transaction on the master, committing when fn succeeds and
+// rolling back otherwise.
+func inTx(ctx context.Context, db *dbpg.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return wrapDBError(err)
+	}
+
+	return wrapDBError(tx.Commit())
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Vote stores the vote of authorID on a comment, replacing an earlier one, and
+// returns the new score. The comment row is locked first so that concurrent
+// votes on the same comment adjust the score one after another.
+func (r *CommentsRepository) Vote(ctx context.Context, commentID, authorID int64, value int) (int, error) {
+	if commentID == 0 || authorID == 0 {
+		return 0, ErrInvalidID
+	}
+	if value != 1 && value != -1 {
+		return 0, ErrInvalidValue
+	}
+
+	var score int
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockVotedComment(ctx, tx, commentID); err != nil {
+			return err
+		}
+
+		var old int
+		err := tx.QueryRowContext(ctx,
+			`SELECT value FROM comment_votes WHERE comment_id = $1 AND author_id = $2`,
+			commentID, authorID,
+		).Scan(&old)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO comment_votes (comment_id, author_id, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (comment_id, author_id)
+		DO UPDATE SET value = EXCLUDED.value, updated_at = now()`,
+			commentID, authorID, value,
+		)
+		if err != nil {
+			return err
+		}
+
+		score, err = addScore(ctx, tx, commentID, old, value)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return score, nil
+}
+
+// Unvote takes back the vote of authorID and returns the new score. Taking
+// back a vote that was never cast is not an error.
+func (r *CommentsRepository) Unvote(ctx context.Context, commentID, authorID int64) (int, error) {
+	if commentID == 0 || authorID == 0 {
+		return 0, ErrInvalidID
+	}
+
+	var score int
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockVotedComment(ctx, tx, commentID); err != nil {
+			return err
+		}
+
+		var old int
+		err := tx.QueryRowContext(ctx,
+			`DELETE FROM comment_votes WHERE comment_id = $1 AND author_id = $2 RETURNING value`,
+			commentID, authorID,
+		).Scan(&old)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		score, err = addScore(ctx, tx, commentID, old, 0)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return score, nil
+}
+
+// lockVotedComment locks a comment for a score change. Deleted comments
+// cannot be voted on and report ErrNotFound.
+func lockVotedComment(ctx context.Context, tx *sql.Tx, id int64) error {
+	var locked int64
+	return tx.QueryRowContext(ctx,
+		`SELECT id FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id,
+	).Scan(&locked)
+}
+
+// addScore replaces the vote old with the vote value in the counters of a
+// comment, 0 standing for no vote, and returns the new score.
+func addScore(ctx context.Context, tx *sql.Tx, id int64, old, value int) (int, error) {
+	var score int
+	err := tx.QueryRowContext(ctx, `
+	UPDATE comments
+	SET score = score + $3::int - $2::int,
+		upvotes = upvotes + ($3::int = 1)::int - ($2::int = 1)::int,
+		downvotes = downvotes + ($3::int = -1)::int - ($2::int = -1)::int
+	WHERE id = $1
+	RETURNING score`,
+		id, old, value,
+	).Scan(&score)
+	return score, err
+}
+
+// GetVotes returns the votes of authorID on the given comments by comment id.
+// Comments without a vote are missing from the result.
+func (r *CommentsRepository) GetVotes(ctx context.Context, authorID int64, commentIDs []int64) (map[int64]int, error) {
+	votes := make(map[int64]int)
+	if len(commentIDs) == 0 {
+		return votes, nil
+	}
+
+	query := r.sb.
+		Select("comment_id", "value").
+		From("comment_votes").
+		Where(squirrel.Eq{"author_id": authorID, "comment_id": commentIDs})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryWithRetry(ctx, r.strategy, sql, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    int64
+			value int
+		)
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, wrapDBError(err)
+		}
+		votes[id] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return votes, nil
+}
@@ -0,0 +1,135 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsRepository_Votes(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	authors := repository.NewAuthorsRepository(db, strategy)
+
+	alice := models.Author{DisplayName: "Alice"}
+	require.NoError(t, authors.Create(t.Context(), &alice))
+	bob := models.Author{DisplayName: "Bob"}
+	require.NoError(t, authors.Create(t.Context(), &bob))
+
+	com := models.Comment{ThreadID: newThread(t), Content: "vote on me"}
+	require.NoError(t, repo.Create(t.Context(), &com))
+	require.Zero(t, com.Score)
+
+	score, err := repo.Vote(t.Context(), com.ID, alice.ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, score)
+
+	score, err = repo.Vote(t.Context(), com.ID, bob.ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, score)
+
+	// changing a vote replaces it instead of adding a second one
+	score, err = repo.Vote(t.Context(), com.ID, alice.ID, -1)
+	require.NoError(t, err)
+	require.Equal(t, 0, score)
+
+	votes, err := repo.GetVotes(t.Context(), alice.ID, []int64{com.ID})
+	require.NoError(t, err)
+	require.Equal(t, map[int64]int{com.ID: -1}, votes)
+
+	score, err = repo.Unvote(t.Context(), com.ID, alice.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, score)
+
+	score, err = repo.Unvote(t.Context(), com.ID, alice.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, score)
+
+	got, err := repo.GetByID(t.Context(), com.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, got.Score)
+
+	t.Run("deleted comment", func(t *testing.T) {
+		_, err := repo.Delete(t.Context(), com.ID, models.DeletePolicyTombstone, nil)
+		require.NoError(t, err)
+
+		_, err = repo.Vote(t.Context(), com.ID, alice.ID, 1)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsRepository_Sort(t *testing.T) {
+	repo := repository.NewCommentsRepository(db, strategy)
+	authors := repository.NewAuthorsRepository(db, strategy)
+	threadID := newThread(t)
+
+	voters := make([]int64, 4)
+	for i := range voters {
+		a := models.Author{DisplayName: "voter"}
+		require.NoError(t, authors.Create(t.Context(), &a))
+		voters[i] = a.ID
+	}
+
+	// votes per comment in creation order
+	votes := [][]int{
+		{1, 1, 1},
+		{1, -1, 1, -1},
+		{-1},
+		{1},
+	}
+	ids := make([]int64, len(votes))
+	for i, vs := range votes {
+		com := models.Comment{ThreadID: threadID, Content: "sorted"}
+		require.NoError(t, repo.Create(t.Context(), &com))
+		ids[i] = com.ID
+		for j, v := range vs {
+			_, err := repo.Vote(t.Context(), com.ID, voters[j], v)
+			require.NoError(t, err)
+		}
+	}
+
+	tests := []struct {
+		sort     models.Sort
+		expected []int64
+	}{
+		{models.SortOld, []int64{ids[0], ids[1], ids[2], ids[3]}},
+		{models.SortNew, []int64{ids[3], ids[2], ids[1], ids[0]}},
+		{models.SortTop, []int64{ids[0], ids[3], ids[1], ids[2]}},
+		{models.SortControversial, []int64{ids[1], ids[3], ids[2], ids[0]}},
+		// without a clear score the newer comment is hotter
+		{models.SortHot, []int64{ids[0], ids[3], ids[2], ids[1]}},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.sort), func(t *testing.T) {
+			// page one comment at a time so that every step goes through the cursor
+			var (
+				got    []int64
+				cursor *models.Cursor
+			)
+			for {
+				page, err := repo.GetByParent(t.Context(), models.CommentsQuery{
+					ThreadID: threadID,
+					Sort:     tc.sort,
+					Page:     models.Page{Limit: 1, Cursor: cursor},
+				})
+				require.NoError(t, err)
+				for _, c := range page.Items {
+					got = append(got, c.ID)
+				}
+				if !page.HasMore {
+					break
+				}
+				cursor, err = models.DecodeCursor(page.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, tc.sort, cursor.Sort)
+			}
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Package searchql parses the search query language:
+//
+//	author:alice before:2026-01-01 "exact phrase" -spam thread:42
+//
+// Words, "quoted phrases" and words or phrases excluded with a leading minus
+// make up the full text part, which is handed to websearch_to_tsquery. The
+// operators below turn into filters:
+//
+//	author:ID       comments of the author with this id
+//	author:NAME     comments of the author with this display name, NAME may be quoted
+//	thread:ID       comments of one thread
+//	under:ID        replies below the comment at any depth
+//	after:TIME      comments written after TIME
+//	before:TIME     comments written before TIME
+//	is:root         root comments only
+//
+// TIME is an RFC 3339 time or a date, which means its midnight UTC. Words
+// that merely look like operators, such as "note:" or "12:30", stay text.
+package searchql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search query. Zero filters were not given. An author
+// given by a number is reported in AuthorID, by name in Author.
+type Query struct {
+	// Text is the full text part in websearch_to_tsquery syntax.
+	Text      string
+	Author    string
+	AuthorID  int64
+	ThreadID  int64
+	Under     int64
+	After     time.Time
+	Before    time.Time
+	RootsOnly bool
+}
+
+// SyntaxError reports a query that cannot be parsed. Pos is the offset of
+// the offending character, counted in characters from 0.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// ParseTime parses an RFC 3339 time or a date, meaning its midnight UTC.
+func ParseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// Parse parses a search query.
+func Parse(s string) (*Query, error) {
+	p := &parser{src: []rune(s), q: &Query{}, seen: make(map[string]bool)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	p.q.Text = strings.Join(p.terms, " ")
+	return p.q, nil
+}
+
+type parser struct {
+	src   []rune
+	pos   int
+	q     *Query
+	terms []string
+	seen  map[string]bool
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil
+		}
+
+		start := p.pos
+		negated := false
+		if p.src[p.pos] == '-' {
+			negated = true
+			p.pos++
+			if p.pos >= len(p.src) || unicode.IsSpace(p.src[p.pos]) {
+				// a lone minus excludes nothing
+				continue
+			}
+		}
+
+		if p.src[p.pos] == '"' {
+			phrase, err := p.quoted()
+			if err != nil {
+				return err
+			}
+			if phrase != "" {
+				p.addTerm(negated, `"`+phrase+`"`)
+			}
+			continue
+		}
+
+		if name, ok := p.operator(); ok {
+			if negated {
+				return &SyntaxError{Pos: start, Msg: "filter " + name + " cannot be negated"}
+			}
+			if err := p.filter(start, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		p.addTerm(negated, p.word())
+	}
+}
+
+func (p *parser) addTerm(negated bool, term string) {
+	if negated {
+		term = "-" + term
+	}
+	p.terms = append(p.terms, term)
+}
+
+// operator consumes NAME: when NAME is a known operator and reports its name.
+func (p *parser) operator() (string, bool) {
+	end := p.pos
+	for end < len(p.src) && p.src[end] >= 'a' && p.src[end] <= 'z' {
+		end++
+	}
+	if end >= len(p.src) || p.src[end] != ':' {
+		return "", false
+	}
+
+	name := string(p.src[p.pos:end])
+	switch name {
+	case "author", "thread", "under", "after", "before", "is":
+		p.pos = end + 1
+		return name, true
+	}
+	return "", false
+}
+
+// filter reads the value of the operator name, which starts at start, and
+// sets the matching filter.
+func (p *parser) filter(start int, name string) error {
+	if p.seen[name] {
+		return &SyntaxError{Pos: start, Msg: "filter " + name + " is given twice"}
+	}
+	p.seen[name] = true
+
+	valuePos := p.pos
+	var (
+		value string
+		err   error
+	)
+	if p.pos < len(p.src) && p.src[p.pos] == '"' {
+		value, err = p.quoted()
+		if err != nil {
+			return err
+		}
+	} else {
+		value = p.word()
+	}
+	if value == "" {
+		return &SyntaxError{Pos: valuePos, Msg: "filter " + name + " needs a value"}
+	}
+
+	invalid := func(what string) error {
+		return &SyntaxError{Pos: valuePos, Msg: fmt.Sprintf("invalid %s %q", what, value)}
+	}
+
+	switch name {
+	case "author":
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			if id <= 0 {
+				return invalid("author id")
+			}
+			p.q.AuthorID = id
+		} else {
+			p.q.Author = value
+		}
+	case "thread", "under":
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return invalid(name + " id")
+		}
+		if name == "thread" {
+			p.q.ThreadID = id
+		} else {
+			p.q.Under = id
+		}
+	case "after", "before":
+		t, err := ParseTime(value)
+		if err != nil {
+			return invalid("time")
+		}
+		if name == "after" {
+			p.q.After = t
+		} else {
+			p.q.Before = t
+		}
+	case "is":
+		if value != "root" {
+			return &SyntaxError{Pos: valuePos, Msg: fmt.Sprintf("unknown value %q for is", value)}
+		}
+		p.q.RootsOnly = true
+	}
+
+	return nil
+}
+
+// quoted consumes a phrase in double quotes and returns it without them.
+func (p *parser) quoted() (string, error) {
+	open := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return "", &SyntaxError{Pos: open, Msg: "unterminated quoted phrase"}
+	}
+
+	phrase := strings.TrimSpace(string(p.src[open+1 : p.pos]))
+	p.pos++
+	return phrase, nil
+}
+
+// word consumes everything up to the next space or quote.
+func (p *parser) word() string {
+	start := p.pos
+	for p.pos < len(p.src) && !unicode.IsSpace(p.src[p.pos]) && p.src[p.pos] != '"' {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
@@ -0,0 +1,101 @@
+package searchql_test
+
+import (
+	"testing"
+	"time"
+
+	"comment-tree/internal/searchql"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  searchql.Query
+	}{
+		{
+			name:  "example",
+			query: `author:alice before:2026-01-01 "exact phrase" -spam thread:42`,
+			want: searchql.Query{
+				Text:     `"exact phrase" -spam`,
+				Author:   "alice",
+				ThreadID: 42,
+				Before:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "plain words",
+			query: "  гитара   or  барабаны ",
+			want:  searchql.Query{Text: "гитара or барабаны"},
+		},
+		{
+			name:  "quoted author",
+			query: `author:"Alice Smith" is:root under:7 after:2026-01-02T03:04:05Z drums`,
+			want: searchql.Query{
+				Text:      "drums",
+				Author:    "Alice Smith",
+				Under:     7,
+				After:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				RootsOnly: true,
+			},
+		},
+		{
+			name:  "not operators",
+			query: `note: 12:30 http://example.com Внимание: -"bad phrase" -`,
+			want:  searchql.Query{Text: `note: 12:30 http://example.com Внимание: -"bad phrase"`},
+		},
+		{
+			name:  "quote inside a word",
+			query: `foo"bar baz"`,
+			want:  searchql.Query{Text: `foo "bar baz"`},
+		},
+		{
+			name:  "author id",
+			query: "author:42 drums",
+			want:  searchql.Query{Text: "drums", AuthorID: 42},
+		},
+		{
+			name:  "only filters",
+			query: "thread:1",
+			want:  searchql.Query{ThreadID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := searchql.Parse(tt.query)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestParse_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		pos   int
+	}{
+		{"unterminated phrase", `spam "exact phrase`, 5},
+		{"unterminated author", `author:"Alice`, 7},
+		{"missing value", "drums thread: 42", 13},
+		{"invalid thread", "thread:abc", 7},
+		{"invalid author", "author:-3", 7},
+		{"invalid time", "ритм before:вчера", 12},
+		{"negated filter", "drums -author:bob", 6},
+		{"duplicate filter", "thread:1 thread:2", 9},
+		{"unknown is value", "is:reply", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := searchql.Parse(tt.query)
+
+			var syntaxErr *searchql.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.Equal(t, tt.pos, syntaxErr.Pos)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+//go:generate mockgen -source=authors.go -destination=../mocks/authors_mocks.go -package=mocks
+package service
+
+import (
+	"context"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/config"
+	"comment-tree/internal/models"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+type AuthorsRepository interface {
+	Create(ctx context.Context, a *models.Author) error
+	GetByID(ctx context.Context, id int64) (*models.Author, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]*models.Author, error)
+	GetByDisplayName(ctx context.Context, name string) ([]*models.Author, error)
+	SetRole(ctx context.Context, id int64, role models.Role) error
+	EnsureAdmin(ctx context.Context, a *models.Author) error
+}
+
+type AuthorsService struct {
+	repo   AuthorsRepository
+	access access
+	log    *zlog.Zerolog
+}
+
+func NewAuthorsService(repo AuthorsRepository, log *zlog.Zerolog) *AuthorsService {
+	return &AuthorsService{
+		repo:   repo,
+		access: access{authors: repo},
+		log:    log,
+	}
+}
+
+// Create stores the profile of the principal of ctx under the id of their
+// token. Profiles cannot be created for anyone else.
+func (s *AuthorsService) Create(ctx context.Context, a *models.Author) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	a.ID = p.AuthorID
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		s.log.Error().
+			Err(err).
+			Msg("failed to create author")
+		return err
+	}
+	return nil
+}
+
+func (s *AuthorsService) GetByID(ctx context.Context, id int64) (*models.Author, error) {
+	a, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get author")
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetRole assigns a role to an author. Only admins may do that.
+func (s *AuthorsService) SetRole(ctx context.Context, id int64, role models.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	if err := s.access.require(ctx, PermAssignRoles); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetRole(ctx, id, role); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Str("role", string(role)).
+			Msg("failed to set author role")
+		return err
+	}
+	return nil
+}
+
+// Bootstrap makes the configured author an admin, so that a fresh deployment
+// has someone who can assign roles. A zero id disables it.
+func (s *AuthorsService) Bootstrap(ctx context.Context, cfg config.BootstrapAdmin) error {
+	if cfg.ID == 0 {
+		return nil
+	}
+
+	a := &models.Author{ID: cfg.ID, DisplayName: cfg.DisplayName}
+	if a.DisplayName == "" {
+		a.DisplayName = "admin"
+	}
+
+	if err := s.repo.EnsureAdmin(ctx, a); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", cfg.ID).
+			Msg("failed to bootstrap admin")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/config"
+	"comment-tree/internal/mocks"
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/zlog"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthorsService_Create(t *testing.T) {
+	t.Run("own profile", func(t *testing.T) {
+		repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+		svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+		ctx := auth.WithPrincipal(context.Background(), auth.Principal{AuthorID: 42})
+
+		// an id in the body names nobody, the profile belongs to the token
+		a := &models.Author{ID: 1, DisplayName: "Alice"}
+		repo.EXPECT().
+			Create(ctx, &models.Author{ID: 42, DisplayName: "Alice"}).
+			Return(nil)
+
+		require.NoError(t, svc.Create(ctx, a))
+		require.Equal(t, int64(42), a.ID)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+		svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+
+		err := svc.Create(context.Background(), &models.Author{DisplayName: "Alice"})
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+}
+
+func TestAuthorsService_SetRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		caller  models.Role
+		id      int64
+		role    models.Role
+		repoErr error
+		calls   int
+		wantErr error
+	}{
+		{"admin", models.RoleAdmin, 7, models.RoleModerator, nil, 1, nil},
+		{"moderator", models.RoleModerator, 7, models.RoleModerator, nil, 0, service.ErrForbidden},
+		{"user", models.RoleUser, 7, models.RoleAdmin, nil, 0, service.ErrForbidden},
+		{"invalid role", models.RoleAdmin, 7, models.Role("owner"), nil, 0, service.ErrInvalidRole},
+		{"unknown author", models.RoleAdmin, 404, models.RoleModerator, repository.ErrNotFound, 1, repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+			svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+			ctx := auth.WithPrincipal(context.Background(), auth.Principal{AuthorID: 1})
+
+			repo.EXPECT().
+				GetByID(ctx, int64(1)).
+				Return(&models.Author{ID: 1, Role: tt.caller}, nil).
+				AnyTimes()
+			repo.EXPECT().
+				SetRole(ctx, tt.id, tt.role).
+				Return(tt.repoErr).
+				Times(tt.calls)
+
+			err := svc.SetRole(ctx, tt.id, tt.role)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("anonymous", func(t *testing.T) {
+		repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+		svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+
+		err := svc.SetRole(context.Background(), 7, models.RoleModerator)
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+}
+
+func TestAuthorsService_Bootstrap(t *testing.T) {
+	t.Run("idempotent", func(t *testing.T) {
+		repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+		svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+		ctx := context.Background()
+
+		repo.EXPECT().
+			EnsureAdmin(ctx, &models.Author{ID: 1, DisplayName: "admin"}).
+			Return(nil).
+			Times(2)
+
+		cfg := config.BootstrapAdmin{ID: 1}
+		require.NoError(t, svc.Bootstrap(ctx, cfg))
+		require.NoError(t, svc.Bootstrap(ctx, cfg))
+	})
+
+	t.Run("display name", func(t *testing.T) {
+		repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+		svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+		ctx := context.Background()
+
+		repo.EXPECT().
+			EnsureAdmin(ctx, &models.Author{ID: 1, DisplayName: "root"}).
+			Return(nil)
+
+		require.NoError(t, svc.Bootstrap(ctx, config.BootstrapAdmin{ID: 1, DisplayName: "root"}))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		repo := mocks.NewMockAuthorsRepository(gomock.NewController(t))
+		svc := service.NewAuthorsService(repo, &zlog.Zerolog{})
+
+		require.NoError(t, svc.Bootstrap(context.Background(), config.BootstrapAdmin{}))
+	})
+}
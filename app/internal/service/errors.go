@@ -0,0 +1,31 @@
+package service
+
+import "errors"
+
+var (
+	ErrInvalidDeletePolicy = errors.New("invalid delete policy")
+	ErrValidation          = errors.New("validation failed")
+	ErrThreadRequired      = errors.New("thread_id is required to list root comments")
+	ErrUnauthenticated     = errors.New("authentication required")
+	ErrForbidden           = errors.New("permission denied")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrInvalidReaction     = errors.New("reaction is not allowed")
+	ErrInvalidLanguage     = errors.New("search language is not allowed")
+	ErrAmbiguousAuthor     = errors.New("several authors have this display name")
+	ErrProfileRequired     = errors.New("author profile required, create it with POST /authors")
+)
+
+// ValidationError explains why a comment was rejected. It matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+)
+
+// Move attaches a comment with all of its replies to another parent in the
+// same thread, or turns it into a root comment when parentID is nil.
+func (s *CommentsService) Move(ctx context.Context, id int64, parentID *int64) (*models.Comment, error) {
+	if err := s.access.require(ctx, PermMoveComments); err != nil {
+		return nil, err
+	}
+
+	com, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get comment")
+		return nil, err
+	}
+
+	if parentID != nil {
+		if err := s.validateMove(ctx, com, *parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repo.Move(ctx, id, parentID)
+	if errors.Is(err, repository.ErrCycle) {
+		return nil, &ValidationError{Field: "parent_id", Reason: "comment cannot be moved under its own reply"}
+	}
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to move comment")
+		return nil, err
+	}
+
+	moved, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get moved comment")
+		return nil, err
+	}
+
+	if err := s.present(ctx, []*models.Comment{moved}); err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
+
+// validateMove checks that com can be attached to parentID: the parent exists
+// in the same thread, is not deleted and the deepest reply of com stays within
+// the configured nesting depth. Cycles are caught by the repository.
+func (s *CommentsService) validateMove(ctx context.Context, com *models.Comment, parentID int64) error {
+	if parentID == com.ID {
+		return &ValidationError{Field: "parent_id", Reason: "comment cannot be moved under its own reply"}
+	}
+
+	parent, err := s.repo.GetByID(ctx, parentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &ValidationError{Field: "parent_id", Reason: "parent comment does not exist"}
+	}
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("parent_id", parentID).
+			Msg("failed to get parent comment")
+		return err
+	}
+
+	if parent.DeletedAt != nil {
+		return &ValidationError{Field: "parent_id", Reason: "parent comment is deleted"}
+	}
+	if parent.ThreadID != com.ThreadID {
+		return &ValidationError{Field: "parent_id", Reason: "parent comment belongs to another thread"}
+	}
+
+	if s.cfg.MaxDepth <= 0 {
+		return nil
+	}
+
+	ancestors, err := s.repo.GetAncestors(ctx, parentID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("parent_id", parentID).
+			Msg("failed to get parent ancestors")
+		return err
+	}
+
+	height, err := s.repo.GetHeight(ctx, com.ID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", com.ID).
+			Msg("failed to get comment height")
+		return err
+	}
+
+	// com lands one level below its parent and its deepest reply height
+	// levels below that
+	if int64(len(ancestors))+1+height > int64(s.cfg.MaxDepth) {
+		return &ValidationError{
+			Field:  "parent_id",
+			Reason: fmt.Sprintf("maximum nesting depth of %d reached", s.cfg.MaxDepth),
+		}
+	}
+
+	return nil
+}
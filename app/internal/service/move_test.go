@@ -0,0 +1,160 @@
+package service_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsService_Move(t *testing.T) {
+	threadID := int64(1)
+	parentID := int64(2)
+	com := &models.Comment{ID: 5, ThreadID: threadID}
+
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(com, nil)
+		m.repo.EXPECT().
+			GetByID(ctx, parentID).
+			Return(&models.Comment{ID: parentID, ThreadID: threadID}, nil)
+		m.repo.EXPECT().
+			GetAncestors(ctx, parentID).
+			Return([]*models.Comment{}, nil)
+		m.repo.EXPECT().
+			GetHeight(ctx, int64(5)).
+			Return(int64(1), nil)
+		m.repo.EXPECT().
+			Move(ctx, int64(5), &parentID).
+			Return(nil)
+		m.repo.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(&models.Comment{ID: 5, ThreadID: threadID, ParentID: &parentID}, nil)
+		m.repo.EXPECT().
+			GetVotes(ctx, int64(1), []int64{5}).
+			Return(map[int64]int{}, nil)
+
+		res, err := svc.Move(ctx, 5, &parentID)
+		require.NoError(t, err)
+		require.Equal(t, &parentID, res.ParentID)
+	})
+
+	t.Run("to root", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleAdmin)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(com, nil).
+			Times(2)
+		m.repo.EXPECT().
+			Move(ctx, int64(5), nil).
+			Return(nil)
+		m.repo.EXPECT().
+			GetVotes(ctx, int64(1), []int64{5}).
+			Return(map[int64]int{}, nil)
+
+		_, err := svc.Move(ctx, 5, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleUser)
+
+		_, err := svc.Move(ctx, 5, &parentID)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(com, nil)
+		m.repo.EXPECT().
+			GetByID(ctx, parentID).
+			Return(&models.Comment{ID: parentID, ThreadID: threadID}, nil)
+		m.repo.EXPECT().
+			GetAncestors(ctx, parentID).
+			Return([]*models.Comment{com}, nil)
+		m.repo.EXPECT().
+			GetHeight(ctx, int64(5)).
+			Return(int64(0), nil)
+		m.repo.EXPECT().
+			Move(ctx, int64(5), &parentID).
+			Return(repository.ErrCycle)
+
+		_, err := svc.Move(ctx, 5, &parentID)
+		require.ErrorIs(t, err, service.ErrValidation)
+	})
+
+	t.Run("itself", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(com, nil)
+
+		self := com.ID
+		_, err := svc.Move(ctx, 5, &self)
+		require.ErrorIs(t, err, service.ErrValidation)
+	})
+
+	invalid := []struct {
+		name   string
+		parent *models.Comment
+		err    error
+	}{
+		{name: "missing parent", err: repository.ErrNotFound},
+		{name: "other thread", parent: &models.Comment{ID: parentID, ThreadID: threadID + 1}},
+	}
+
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, m, ctx := newTestService(t, testConfig)
+			ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+			m.repo.EXPECT().
+				GetByID(ctx, int64(5)).
+				Return(com, nil)
+			m.repo.EXPECT().
+				GetByID(ctx, parentID).
+				Return(tc.parent, tc.err)
+
+			_, err := svc.Move(ctx, 5, &parentID)
+			require.ErrorIs(t, err, service.ErrValidation)
+		})
+	}
+
+	t.Run("too deep", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(com, nil)
+		m.repo.EXPECT().
+			GetByID(ctx, parentID).
+			Return(&models.Comment{ID: parentID, ThreadID: threadID}, nil)
+		m.repo.EXPECT().
+			GetAncestors(ctx, parentID).
+			Return([]*models.Comment{{ID: 1}}, nil)
+		// max depth of the test service is 2
+		m.repo.EXPECT().
+			GetHeight(ctx, int64(5)).
+			Return(int64(1), nil)
+
+		_, err := svc.Move(ctx, 5, &parentID)
+		require.ErrorIs(t, err, service.ErrValidation)
+	})
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/models"
+)
+
+// Reactions returns the configured set of reactions.
+func (s *CommentsService) Reactions() []string {
+	return s.cfg.Reactions
+}
+
+// AddReaction puts reaction of the principal of ctx on a comment and returns
+// the reactions of the comment afterwards.
+func (s *CommentsService) AddReaction(ctx context.Context, id int64, reaction string) (*models.ReactionsResult, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if !slices.Contains(s.cfg.Reactions, reaction) {
+		return nil, ErrInvalidReaction
+	}
+	if _, err := s.actor(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddReaction(ctx, id, p.AuthorID, reaction); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Str("reaction", reaction).
+			Msg("failed to add reaction")
+		return nil, err
+	}
+
+	return s.reactionsOf(ctx, id, p.AuthorID)
+}
+
+// RemoveReaction takes back reaction of the principal of ctx. Reactions that
+// were dropped from the configured set can still be removed.
+func (s *CommentsService) RemoveReaction(ctx context.Context, id int64, reaction string) (*models.ReactionsResult, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	if err := s.repo.RemoveReaction(ctx, id, p.AuthorID, reaction); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Str("reaction", reaction).
+			Msg("failed to remove reaction")
+		return nil, err
+	}
+
+	return s.reactionsOf(ctx, id, p.AuthorID)
+}
+
+func (s *CommentsService) reactionsOf(ctx context.Context, id, authorID int64) (*models.ReactionsResult, error) {
+	reactions, err := s.repo.GetReactions(ctx, &authorID, []int64{id})
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to load reactions")
+		return nil, err
+	}
+
+	res := &models.ReactionsResult{CommentID: id, Reactions: reactions[id]}
+	if res.Reactions == nil {
+		res.Reactions = []models.Reaction{}
+	}
+	return res, nil
+}
+
+// attachReactions fills in the reactions of coms unless reactions are off.
+func (s *CommentsService) attachReactions(ctx context.Context, coms []*models.Comment) error {
+	if len(s.cfg.Reactions) == 0 || len(coms) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(coms))
+	for _, com := range coms {
+		ids = append(ids, com.ID)
+	}
+
+	reactions, err := s.repo.GetReactions(ctx, actorID(ctx), ids)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Msg("failed to load reactions")
+		return err
+	}
+
+	for _, com := range coms {
+		com.Reactions = reactions[com.ID]
+	}
+
+	return nil
+}
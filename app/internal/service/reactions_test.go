@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/config"
+	"comment-tree/internal/models"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testReactionsConfig = config.Comments{Reactions: []string{"heart", "tada"}}
+
+func TestCommentsService_AddReaction(t *testing.T) {
+	authorID := int64(7)
+
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testReactionsConfig)
+		ctx = asRole(ctx, m, authorID, models.RoleUser)
+
+		m.repo.EXPECT().
+			AddReaction(ctx, int64(1), authorID, "heart").
+			Return(nil)
+		m.repo.EXPECT().
+			GetReactions(ctx, &authorID, []int64{1}).
+			Return(map[int64][]models.Reaction{1: {{Name: "heart", Count: 2, Mine: true}}}, nil)
+
+		res, err := svc.AddReaction(ctx, 1, "heart")
+		require.NoError(t, err)
+		require.Equal(t, &models.ReactionsResult{
+			CommentID: 1,
+			Reactions: []models.Reaction{{Name: "heart", Count: 2, Mine: true}},
+		}, res)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testReactionsConfig)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: authorID})
+
+		_, err := svc.AddReaction(ctx, 1, "poop")
+		require.ErrorIs(t, err, service.ErrInvalidReaction)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testReactionsConfig)
+
+		_, err := svc.AddReaction(ctx, 1, "heart")
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+}
+
+func TestCommentsService_RemoveReaction(t *testing.T) {
+	svc, m, ctx := newTestService(t, testReactionsConfig)
+
+	authorID := int64(7)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: authorID})
+
+	m.repo.EXPECT().
+		RemoveReaction(ctx, int64(1), authorID, "heart").
+		Return(nil)
+	m.repo.EXPECT().
+		GetReactions(ctx, &authorID, []int64{1}).
+		Return(map[int64][]models.Reaction{}, nil)
+
+	res, err := svc.RemoveReaction(ctx, 1, "heart")
+	require.NoError(t, err)
+	require.Equal(t, &models.ReactionsResult{CommentID: 1, Reactions: []models.Reaction{}}, res)
+}
+
+func TestCommentsService_GetByParent_Reactions(t *testing.T) {
+	svc, m, ctx := newTestService(t, testReactionsConfig)
+
+	q := models.CommentsQuery{ThreadID: 1, Sort: models.SortOld, Page: models.Page{Limit: 10}}
+
+	m.repo.EXPECT().
+		GetByParent(ctx, q).
+		Return(&models.CommentsPage{
+			Items: []*models.Comment{{ID: 1}, {ID: 2}, {ID: 3}},
+		}, nil)
+	// one query for the whole page
+	m.repo.EXPECT().
+		GetReactions(ctx, nil, []int64{1, 2, 3}).
+		Return(map[int64][]models.Reaction{
+			2: {{Name: "tada", Count: 1}},
+		}, nil)
+
+	res, err := svc.GetByParent(ctx, q)
+	require.NoError(t, err)
+	require.Nil(t, res.Items[0].Reactions)
+	require.Equal(t, []models.Reaction{{Name: "tada", Count: 1}}, res.Items[1].Reactions)
+}
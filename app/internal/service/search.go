@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/searchql"
+)
+
+// Default highlight markers, used when the configuration leaves them empty.
+const (
+	defaultStartSel = "<mark>"
+	defaultStopSel  = "</mark>"
+)
+
+// detectedConfigs maps the languages langdetect knows to text search
+// configurations.
+var detectedConfigs = map[string]string{
+	"en": "english",
+	"ru": "russian",
+}
+
+// defaultLanguage is the text search configuration new comments are indexed
+// with unless their language is detected.
+func (s *CommentsService) defaultLanguage() string {
+	if s.cfg.Search.Language == "" {
+		return models.DefaultSearchConfig
+	}
+	return s.cfg.Search.Language
+}
+
+// languages returns every configured text search configuration, the default
+// one first.
+func (s *CommentsService) languages() []string {
+	langs := []string{s.defaultLanguage()}
+	for _, lang := range s.cfg.Search.Languages {
+		if !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// searchLanguages returns the configurations a search asking for lang is
+// parsed with: lang alone when it is configured, all of them when it is empty.
+func (s *CommentsService) searchLanguages(lang string) ([]string, error) {
+	langs := s.languages()
+	if lang == "" {
+		return langs, nil
+	}
+	if !slices.Contains(langs, lang) {
+		return nil, ErrInvalidLanguage
+	}
+	return []string{lang}, nil
+}
+
+// index sets the language of com and the configuration it is indexed with:
+// the one of the detected language when detection is on and that
+// configuration is enabled, the default one otherwise.
+func (s *CommentsService) index(com *models.Comment) {
+	com.Lang = ""
+	com.SearchConfig = s.defaultLanguage()
+	if s.detector == nil {
+		return
+	}
+
+	com.Lang = s.detector.Detect(com.Content)
+	if cfg, ok := detectedConfigs[com.Lang]; ok && slices.Contains(s.languages(), cfg) {
+		com.SearchConfig = cfg
+	}
+}
+
+// reindexBatch is how many comments Reindex reads and writes at once.
+const reindexBatch = 500
+
+// Reindex recomputes the language and search configuration of every comment
+// from the current configuration, as if it was written now, and returns how
+// many comments changed.
+func (s *CommentsService) Reindex(ctx context.Context) (int64, error) {
+	var changed, afterID int64
+	for {
+		coms, err := s.repo.GetIndexBatch(ctx, afterID, reindexBatch)
+		if err != nil {
+			s.log.Error().
+				Err(err).
+				Int64("after_id", afterID).
+				Msg("failed to read comments to reindex")
+			return changed, err
+		}
+		if len(coms) == 0 {
+			return changed, nil
+		}
+		afterID = coms[len(coms)-1].ID
+
+		stale := make([]*models.Comment, 0, len(coms))
+		for _, com := range coms {
+			cfg, lang := com.SearchConfig, com.Lang
+			s.index(com)
+			if com.SearchConfig != cfg || com.Lang != lang {
+				stale = append(stale, com)
+			}
+		}
+
+		if len(stale) == 0 {
+			continue
+		}
+		if err := s.repo.SetIndex(ctx, stale); err != nil {
+			s.log.Error().
+				Err(err).
+				Int64("after_id", afterID).
+				Msg("failed to reindex comments")
+			return changed, err
+		}
+		changed += int64(len(stale))
+	}
+}
+
+// highlight returns the configured snippet options with the default markers
+// filled in.
+func (s *CommentsService) highlight() models.Highlight {
+	h := models.Highlight{
+		StartSel:     s.cfg.Search.Highlight.StartSel,
+		StopSel:      s.cfg.Search.Highlight.StopSel,
+		MaxFragments: s.cfg.Search.Highlight.MaxFragments,
+	}
+	if h.StartSel == "" {
+		h.StartSel = defaultStartSel
+	}
+	if h.StopSel == "" {
+		h.StopSel = defaultStopSel
+	}
+	return h
+}
+
+// applySearchQL parses q.Query, leaves its full text part in q.Query and
+// sets the filters it names. Filters in the query text take precedence over
+// those already set on q. An author given by name is returned for the caller
+// to resolve.
+func applySearchQL(q *models.SearchQuery) (string, error) {
+	parsed, err := searchql.Parse(q.Query)
+	if err != nil {
+		return "", err
+	}
+
+	q.Query = parsed.Text
+	if parsed.AuthorID != 0 {
+		q.AuthorID = parsed.AuthorID
+	}
+	if parsed.ThreadID != 0 {
+		q.ThreadID = parsed.ThreadID
+	}
+	if parsed.Under != 0 {
+		q.Under = parsed.Under
+	}
+	if !parsed.After.IsZero() {
+		q.CreatedAfter = parsed.After
+	}
+	if !parsed.Before.IsZero() {
+		q.CreatedBefore = parsed.Before
+	}
+	if parsed.RootsOnly {
+		q.RootsOnly = true
+	}
+
+	return parsed.Author, nil
+}
+
+// authorByName resolves a display name to the id of the only author who has
+// it. Names are not unique, so rather than searching the comments of everyone
+// going by the name, a name shared by several authors fails with
+// ErrAmbiguousAuthor. An unknown name reports repository.ErrNotFound.
+func (s *CommentsService) authorByName(ctx context.Context, name string) (int64, error) {
+	authors, err := s.authors.GetByDisplayName(ctx, name)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Str("name", name).
+			Msg("failed to find author")
+		return 0, err
+	}
+
+	switch len(authors) {
+	case 0:
+		return 0, repository.ErrNotFound
+	case 1:
+		return authors[0].ID, nil
+	default:
+		return 0, ErrAmbiguousAuthor
+	}
+}
@@ -0,0 +1,254 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"comment-tree/internal/config"
+	"comment-tree/internal/models"
+	"comment-tree/internal/searchql"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testSearchConfig = config.Search{Language: "english", Languages: []string{"russian", "simple"}}
+
+// testHighlight is what the service asks for when nothing is configured.
+var testHighlight = models.Highlight{StartSel: "<mark>", StopSel: "</mark>"}
+
+func TestCommentsService_SearchLanguage(t *testing.T) {
+	page := &models.SearchPage{Items: []*models.SearchResult{}}
+
+	t.Run("all configured", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{Search: testSearchConfig})
+
+		m.repo.EXPECT().
+			Search(ctx, models.SearchQuery{
+				Query: "q", Langs: []string{"english", "russian", "simple"}, Highlight: testHighlight,
+			}).
+			Return(page, nil)
+
+		_, err := svc.Search(ctx, models.SearchQuery{Query: "q"})
+		require.NoError(t, err)
+	})
+
+	t.Run("one", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{Search: testSearchConfig})
+
+		m.repo.EXPECT().
+			Search(ctx, models.SearchQuery{
+				Query: "q", Lang: "russian", Langs: []string{"russian"}, Highlight: testHighlight,
+			}).
+			Return(page, nil)
+
+		_, err := svc.Search(ctx, models.SearchQuery{Query: "q", Lang: "russian"})
+		require.NoError(t, err)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, config.Comments{Search: testSearchConfig})
+
+		_, err := svc.Search(ctx, models.SearchQuery{Query: "q", Lang: "german"})
+		require.ErrorIs(t, err, service.ErrInvalidLanguage)
+	})
+}
+
+func TestCommentsService_CreateLanguage(t *testing.T) {
+	detect := config.Search{Language: "russian", Languages: []string{"english"}, Detect: true}
+
+	tests := []struct {
+		name       string
+		cfg        config.Search
+		content    string
+		wantLang   string
+		wantConfig string
+	}{
+		{"no detection", testSearchConfig, "Привет, как перенести ветку?", "", "english"},
+		{"english", detect, "Could someone explain how threads work?", "en", "english"},
+		{"russian", detect, "Подскажите, как перенести ветку обсуждения?", "ru", "russian"},
+		{"undetected", detect, "ok", "", "russian"},
+		{
+			"not configured",
+			config.Search{Language: "simple", Detect: true},
+			"Could someone explain how threads work?", "en", "simple",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m, ctx := newTestService(t, config.Comments{Search: tt.cfg})
+
+			m.threads.EXPECT().
+				GetByID(ctx, int64(1)).
+				Return(&models.Thread{ID: 1}, nil)
+			m.repo.EXPECT().
+				Create(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, com *models.Comment) error {
+					require.Equal(t, tt.wantLang, com.Lang)
+					require.Equal(t, tt.wantConfig, com.SearchConfig)
+					return nil
+				})
+
+			err := svc.Create(ctx, &models.Comment{ThreadID: 1, Content: tt.content})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCommentsService_SearchHighlight(t *testing.T) {
+	cfg := testSearchConfig
+	cfg.Highlight = config.Highlight{StartSel: "[", StopSel: "]", MaxFragments: 3}
+	svc, m, ctx := newTestService(t, config.Comments{Search: cfg})
+
+	found := &models.SearchPage{Items: []*models.SearchResult{
+		{Comment: &models.Comment{ID: 1, Content: "hello world"}, Highlight: "[hello] world", Rank: 0.5},
+	}}
+	m.repo.EXPECT().
+		Search(ctx, gomock.Any()).
+		DoAndReturn(func(_ context.Context, q models.SearchQuery) (*models.SearchPage, error) {
+			require.Equal(t, models.Highlight{StartSel: "[", StopSel: "]", MaxFragments: 3}, q.Highlight)
+			return found, nil
+		})
+
+	res, err := svc.Search(ctx, models.SearchQuery{Query: "hello"})
+	require.NoError(t, err)
+	require.Equal(t, found, res)
+}
+
+func TestCommentsService_SearchQueryLanguage(t *testing.T) {
+	t.Run("filters", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{})
+
+		m.authors.EXPECT().
+			GetByDisplayName(ctx, "alice").
+			Return([]*models.Author{{ID: 5, DisplayName: "Alice"}}, nil)
+		m.repo.EXPECT().
+			Search(ctx, models.SearchQuery{
+				Query:         `"exact phrase" -spam`,
+				ThreadID:      42,
+				AuthorID:      5,
+				CreatedBefore: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				Langs:         []string{models.DefaultSearchConfig},
+				Highlight:     testHighlight,
+			}).
+			Return(&models.SearchPage{Items: []*models.SearchResult{}}, nil)
+
+		_, err := svc.Search(ctx, models.SearchQuery{
+			Query:    `author:alice before:2026-01-01 "exact phrase" -spam thread:42`,
+			ThreadID: 1,
+			AuthorID: 3,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("author id", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{})
+
+		m.repo.EXPECT().
+			Search(ctx, models.SearchQuery{
+				Query:     "drums",
+				AuthorID:  7,
+				Langs:     []string{models.DefaultSearchConfig},
+				Highlight: testHighlight,
+			}).
+			Return(&models.SearchPage{Items: []*models.SearchResult{}}, nil)
+
+		_, err := svc.Search(ctx, models.SearchQuery{Query: "author:7 drums"})
+		require.NoError(t, err)
+	})
+
+	t.Run("ambiguous author", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{})
+
+		m.authors.EXPECT().
+			GetByDisplayName(ctx, "alice").
+			Return([]*models.Author{{ID: 5, DisplayName: "Alice"}, {ID: 6, DisplayName: "alice"}}, nil)
+
+		res, err := svc.Search(ctx, models.SearchQuery{Query: "author:alice drums"})
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrAmbiguousAuthor)
+	})
+
+	t.Run("unknown author", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{})
+
+		m.authors.EXPECT().
+			GetByDisplayName(ctx, "nobody").
+			Return([]*models.Author{}, nil)
+
+		res, err := svc.Search(ctx, models.SearchQuery{Query: "author:nobody drums"})
+		require.NoError(t, err)
+		require.Empty(t, res.Items)
+	})
+
+	t.Run("filters only", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{})
+
+		m.repo.EXPECT().
+			Search(ctx, models.SearchQuery{
+				ThreadID:  42,
+				RootsOnly: true,
+				Langs:     []string{models.DefaultSearchConfig},
+				Highlight: testHighlight,
+			}).
+			Return(&models.SearchPage{Items: []*models.SearchResult{}}, nil)
+
+		_, err := svc.Search(ctx, models.SearchQuery{Query: "thread:42 is:root"})
+		require.NoError(t, err)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, config.Comments{})
+
+		_, err := svc.Search(ctx, models.SearchQuery{Query: `spam "exact phrase`})
+
+		var syntaxErr *searchql.SyntaxError
+		require.ErrorAs(t, err, &syntaxErr)
+		require.Equal(t, 5, syntaxErr.Pos)
+	})
+}
+
+func TestCommentsService_Reindex(t *testing.T) {
+	detect := config.Search{Language: "russian", Languages: []string{"english"}, Detect: true}
+
+	t.Run("stale only", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{Search: detect})
+
+		m.repo.EXPECT().
+			GetIndexBatch(ctx, int64(0), gomock.Any()).
+			Return([]*models.Comment{
+				{ID: 1, Content: "Could someone explain how threads work?", SearchConfig: "russian"},
+				{ID: 2, Content: "Подскажите, как перенести ветку обсуждения?", SearchConfig: "russian", Lang: "ru"},
+			}, nil)
+		m.repo.EXPECT().
+			SetIndex(ctx, []*models.Comment{
+				{ID: 1, Content: "Could someone explain how threads work?", SearchConfig: "english", Lang: "en"},
+			}).
+			Return(nil)
+		m.repo.EXPECT().
+			GetIndexBatch(ctx, int64(2), gomock.Any()).
+			Return([]*models.Comment{}, nil)
+
+		changed, err := svc.Reindex(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), changed)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{Search: testSearchConfig})
+
+		m.repo.EXPECT().
+			GetIndexBatch(ctx, int64(0), gomock.Any()).
+			Return([]*models.Comment{{ID: 3, Content: "ok", SearchConfig: "english"}}, nil)
+		m.repo.EXPECT().
+			GetIndexBatch(ctx, int64(3), gomock.Any()).
+			Return([]*models.Comment{}, nil)
+
+		changed, err := svc.Reindex(ctx)
+		require.NoError(t, err)
+		require.Zero(t, changed)
+	})
+}
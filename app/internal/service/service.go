@@ -2,8 +2,14 @@
 package service
 
 import (
+	"comment-tree/internal/auth"
+	"comment-tree/internal/config"
+	"comment-tree/internal/langdetect"
 	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/wb-go/wbf/zlog"
 )
@@ -11,24 +17,82 @@ import (
 type CommentsRepository interface {
 	Create(ctx context.Context, com *models.Comment) error
 	Update(ctx context.Context, com *models.Comment) error
-	Delete(ctx context.Context, id int64) error
-	GetByParent(ctx context.Context, parentID *int64, limit, offset int64) ([]*models.Comment, error)
-	Search(ctx context.Context, query string, limit, offset int64) ([]*models.Comment, error)
+	Delete(ctx context.Context, id int64, policy models.DeletePolicy, deletedBy *int64) (int64, error)
+	GetByParent(ctx context.Context, q models.CommentsQuery) (*models.CommentsPage, error)
+	Search(ctx context.Context, q models.SearchQuery) (*models.SearchPage, error)
+	GetSubtree(ctx context.Context, rootID, maxDepth, perLevelLimit int64) (*models.CommentNode, error)
+	Purge(ctx context.Context, id int64) error
+	GetRevisions(ctx context.Context, commentID int64) ([]*models.Revision, error)
+	GetRevision(ctx context.Context, commentID int64, rev int) (*models.Revision, error)
+	GetByID(ctx context.Context, id int64) (*models.Comment, error)
+	GetAncestors(ctx context.Context, id int64) ([]*models.Comment, error)
+	GetHeight(ctx context.Context, id int64) (int64, error)
+	Move(ctx context.Context, id int64, parentID *int64) error
+	SetLocked(ctx context.Context, id int64, locked bool) error
+	Vote(ctx context.Context, commentID, authorID int64, value int) (int, error)
+	Unvote(ctx context.Context, commentID, authorID int64) (int, error)
+	GetVotes(ctx context.Context, authorID int64, commentIDs []int64) (map[int64]int, error)
+	AddReaction(ctx context.Context, commentID, authorID int64, reaction string) error
+	RemoveReaction(ctx context.Context, commentID, authorID int64, reaction string) error
+	GetReactions(ctx context.Context, authorID *int64, commentIDs []int64) (map[int64][]models.Reaction, error)
+	GetIndexBatch(ctx context.Context, afterID int64, limit int) ([]*models.Comment, error)
+	SetIndex(ctx context.Context, coms []*models.Comment) error
 }
 
 type CommentsService struct {
-	repo CommentsRepository
-	log  *zlog.Zerolog
+	repo    CommentsRepository
+	threads ThreadsRepository
+	authors AuthorsRepository
+	access  access
+	cfg     config.Comments
+	// detector is nil when language detection is off.
+	detector *langdetect.Detector
+	log      *zlog.Zerolog
 }
 
-func NewCommentsService(repo CommentsRepository, log *zlog.Zerolog) *CommentsService {
-	return &CommentsService{
-		repo: repo,
-		log:  log,
+func NewCommentsService(
+	repo CommentsRepository,
+	threads ThreadsRepository,
+	authors AuthorsRepository,
+	cfg config.Comments,
+	log *zlog.Zerolog,
+) *CommentsService {
+	s := &CommentsService{
+		repo:    repo,
+		threads: threads,
+		authors: authors,
+		access:  access{authors: authors},
+		cfg:     cfg,
+		log:     log,
 	}
+	if cfg.Search.Detect {
+		s.detector = langdetect.New()
+	}
+
+	return s
 }
 
+// Create stores a new comment written by the principal of ctx, or an anonymous
+// one when the request carries no principal.
 func (s *CommentsService) Create(ctx context.Context, com *models.Comment) error {
+	authorID, err := s.actor(ctx)
+	if err != nil {
+		return err
+	}
+	com.AuthorID = authorID
+
+	if com.ParentID != nil {
+		if err := s.validateParent(ctx, com); err != nil {
+			return err
+		}
+	}
+
+	if err := s.validateThread(ctx, com.ThreadID); err != nil {
+		return err
+	}
+
+	s.index(com)
+
 	if err := s.repo.Create(ctx, com); err != nil {
 		s.log.Error().
 			Err(err).
@@ -38,7 +102,95 @@ func (s *CommentsService) Create(ctx context.Context, com *models.Comment) error
 	return nil
 }
 
+// validateParent checks that the reply com can be attached to its parent: the
+// parent exists, is not deleted, no comment above the reply is locked and the
+// reply stays within the configured nesting depth. The reply joins the thread
+// of its parent.
+func (s *CommentsService) validateParent(ctx context.Context, com *models.Comment) error {
+	parentID := *com.ParentID
+	parent, err := s.repo.GetByID(ctx, parentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &ValidationError{Field: "parent_id", Reason: "parent comment does not exist"}
+	}
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("parent_id", parentID).
+			Msg("failed to get parent comment")
+		return err
+	}
+
+	if parent.DeletedAt != nil {
+		return &ValidationError{Field: "parent_id", Reason: "parent comment is deleted"}
+	}
+
+	if com.ThreadID != 0 && com.ThreadID != parent.ThreadID {
+		return &ValidationError{Field: "thread_id", Reason: "parent comment belongs to another thread"}
+	}
+	com.ThreadID = parent.ThreadID
+
+	ancestors, err := s.repo.GetAncestors(ctx, parentID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("parent_id", parentID).
+			Msg("failed to get parent ancestors")
+		return err
+	}
+
+	for _, c := range append(ancestors, parent) {
+		if c.LockedAt != nil {
+			return &ValidationError{Field: "parent_id", Reason: "thread is locked"}
+		}
+	}
+
+	// the reply sits one level below its parent, whose depth is the number of its ancestors
+	if s.cfg.MaxDepth > 0 && len(ancestors)+1 > s.cfg.MaxDepth {
+		return &ValidationError{
+			Field:  "parent_id",
+			Reason: fmt.Sprintf("maximum nesting depth of %d reached", s.cfg.MaxDepth),
+		}
+	}
+
+	return nil
+}
+
+// validateThread checks that comments can be added to the thread.
+func (s *CommentsService) validateThread(ctx context.Context, threadID int64) error {
+	if threadID == 0 {
+		return &ValidationError{Field: "thread_id", Reason: "thread_id is required"}
+	}
+
+	th, err := s.threads.GetByID(ctx, threadID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &ValidationError{Field: "thread_id", Reason: "thread does not exist"}
+	}
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("thread_id", threadID).
+			Msg("failed to get thread")
+		return err
+	}
+
+	if th.LockedAt != nil {
+		return &ValidationError{Field: "thread_id", Reason: "thread is locked"}
+	}
+
+	return nil
+}
+
+// Update changes a comment on behalf of its author or a moderator.
 func (s *CommentsService) Update(ctx context.Context, com *models.Comment) error {
+	if err := s.authorize(ctx, com.ID, PermEditAnyComment); err != nil {
+		return err
+	}
+
+	// without detection an edit keeps the configuration the comment has
+	if s.detector != nil {
+		s.index(com)
+	}
+
 	if err := s.repo.Update(ctx, com); err != nil {
 		s.log.Error().
 			Err(err).
@@ -48,34 +200,429 @@ func (s *CommentsService) Update(ctx context.Context, com *models.Comment) error
 	return nil
 }
 
-func (s *CommentsService) Delete(ctx context.Context, id int64) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
+// Delete removes a comment using policy, or the configured default policy,
+// tombstone unless configured, when policy is empty. The author and
+// moderators may tombstone a comment. Taking the replies of others along
+// needs more: reparent is for moderators and cascade for admins, while an
+// author may still remove their own comment without replies under any policy.
+func (s *CommentsService) Delete(ctx context.Context, id int64, policy models.DeletePolicy) (*models.DeleteResult, error) {
+	if policy == "" {
+		policy = models.DeletePolicy(s.cfg.DeletePolicy)
+	}
+	if policy == "" {
+		policy = models.DeletePolicyTombstone
+	}
+	if !policy.Valid() {
+		return nil, ErrInvalidDeletePolicy
+	}
+
+	run, err := s.authorizeDelete(ctx, id, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedBy, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := s.repo.Delete(ctx, id, run, deletedBy)
+	if err != nil {
 		s.log.Error().
 			Err(err).
+			Str("policy", string(policy)).
 			Msg("failed to delete comment")
-		return err
+		return nil, err
 	}
-	return nil
+
+	return &models.DeleteResult{
+		ID:       id,
+		Policy:   policy,
+		Affected: affected,
+	}, nil
 }
 
-func (s *CommentsService) GetByParent(ctx context.Context, parentID *int64, limit, offset int64) ([]*models.Comment, error) {
-	coms, err := s.repo.GetByParent(ctx, parentID, limit, offset)
+// GetByParent lists the replies of a comment, or the root comments of a thread
+// when no parent is given.
+func (s *CommentsService) GetByParent(ctx context.Context, q models.CommentsQuery) (*models.CommentsPage, error) {
+	if q.ParentID == nil && q.ThreadID == 0 {
+		return nil, ErrThreadRequired
+	}
+
+	if q.Sort == "" {
+		q.Sort = models.SortOld
+	}
+	if !q.Sort.Valid() {
+		return nil, models.ErrInvalidSort
+	}
+	// a cursor only makes sense in the order it was issued for, cursors from
+	// before sorting existed have no order and belong to old
+	if c := q.Page.Cursor; c != nil && c.Sort != q.Sort && (c.Sort != "" || q.Sort != models.SortOld) {
+		return nil, models.ErrInvalidCursor
+	}
+
+	coms, err := s.repo.GetByParent(ctx, q)
 	if err != nil {
 		s.log.Error().
 			Err(err).
 			Msg("failed to get comments")
 		return nil, err
 	}
+
+	if err := s.present(ctx, coms.Items); err != nil {
+		return nil, err
+	}
+
 	return coms, nil
 }
 
-func (s *CommentsService) Search(ctx context.Context, query string, limit, offset int64) ([]*models.Comment, error) {
-	coms, err := s.repo.Search(ctx, query, limit, offset)
+// Search finds comments matching q and highlights the matched words with the
+// configured markers. q.Query is written in the searchql query language.
+func (s *CommentsService) Search(ctx context.Context, q models.SearchQuery) (*models.SearchPage, error) {
+	author, err := applySearchQL(&q)
+	if err != nil {
+		return nil, err
+	}
+	if author != "" {
+		id, err := s.authorByName(ctx, author)
+		if errors.Is(err, repository.ErrNotFound) {
+			// nobody goes by the name, so nobody wrote anything
+			return &models.SearchPage{Items: []*models.SearchResult{}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q.AuthorID = id
+	}
+
+	langs, err := s.searchLanguages(q.Lang)
+	if err != nil {
+		return nil, err
+	}
+	q.Langs = langs
+	q.Highlight = s.highlight()
+
+	found, err := s.repo.Search(ctx, q)
 	if err != nil {
 		s.log.Error().
 			Err(err).
 			Msg("failed to search comments")
 		return nil, err
 	}
-	return coms, nil
+
+	coms := make([]*models.Comment, 0, len(found.Items))
+	for _, res := range found.Items {
+		coms = append(coms, res.Comment)
+	}
+	if err := s.attach(ctx, coms); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// GetPermalink returns a comment together with its ancestors up to the root
+// and up to depth levels of its replies, so that a single reply can be shown
+// in its context.
+func (s *CommentsService) GetPermalink(ctx context.Context, id, depth, perLevelLimit int64) (*models.Permalink, error) {
+	tree, err := s.repo.GetSubtree(ctx, id, depth, perLevelLimit)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get comment")
+		return nil, err
+	}
+
+	ancestors, err := s.repo.GetAncestors(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get comment ancestors")
+		return nil, err
+	}
+
+	if err := s.present(ctx, append(flattenTree(tree, nil), ancestors...)); err != nil {
+		return nil, err
+	}
+
+	return &models.Permalink{Ancestors: ancestors, Comment: tree}, nil
+}
+
+func (s *CommentsService) GetSubtree(ctx context.Context, rootID, maxDepth, perLevelLimit int64) (*models.CommentNode, error) {
+	tree, err := s.repo.GetSubtree(ctx, rootID, maxDepth, perLevelLimit)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", rootID).
+			Msg("failed to get comments subtree")
+		return nil, err
+	}
+
+	if err := s.present(ctx, flattenTree(tree, nil)); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// Purge removes a comment and its whole subtree for real, unlike Delete which
+// only leaves a tombstone.
+func (s *CommentsService) Purge(ctx context.Context, id int64) error {
+	if err := s.access.require(ctx, PermPurge); err != nil {
+		return err
+	}
+
+	if err := s.repo.Purge(ctx, id); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to purge comment")
+		return err
+	}
+	return nil
+}
+
+// present prepares comments for the caller: deleted comments become
+// tombstones unless the caller may see them, and the authors, votes and
+// reactions are attached.
+func (s *CommentsService) present(ctx context.Context, coms []*models.Comment) error {
+	showDeleted, err := s.access.can(ctx, PermViewDeleted)
+	if err != nil {
+		return err
+	}
+	if !showDeleted {
+		for _, com := range coms {
+			com.Tombstone()
+		}
+	}
+
+	return s.attach(ctx, coms)
+}
+
+// attach loads everything shown next to comments.
+func (s *CommentsService) attach(ctx context.Context, coms []*models.Comment) error {
+	if err := s.attachAuthors(ctx, coms); err != nil {
+		return err
+	}
+	if err := s.attachVotes(ctx, coms); err != nil {
+		return err
+	}
+	return s.attachReactions(ctx, coms)
+}
+
+func flattenTree(node *models.CommentNode, coms []*models.Comment) []*models.Comment {
+	coms = append(coms, &node.Comment)
+	for _, child := range node.Children {
+		coms = flattenTree(child, coms)
+	}
+	return coms
+}
+
+// attachAuthors embeds the public profile of the authors of coms.
+func (s *CommentsService) attachAuthors(ctx context.Context, coms []*models.Comment) error {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, com := range coms {
+		if com.AuthorID != nil && !seen[*com.AuthorID] {
+			seen[*com.AuthorID] = true
+			ids = append(ids, *com.AuthorID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	authors, err := s.authors.GetByIDs(ctx, ids)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Msg("failed to load comment authors")
+		return err
+	}
+
+	byID := make(map[int64]*models.Author, len(authors))
+	for _, a := range authors {
+		byID[a.ID] = a
+	}
+	for _, com := range coms {
+		if com.AuthorID != nil {
+			com.Author = byID[*com.AuthorID]
+		}
+	}
+
+	return nil
+}
+
+// authorize lets the principal of ctx change the comment id when it is its
+// author or its role grants perm. Anonymous comments can only be changed
+// through perm.
+func (s *CommentsService) authorize(ctx context.Context, id int64, perm Permission) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	allowed, err := s.access.can(ctx, perm)
+	if err != nil {
+		return err
+	}
+	if allowed {
+		return nil
+	}
+
+	com, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get comment")
+		return err
+	}
+
+	if com.AuthorID == nil || *com.AuthorID != p.AuthorID {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// authorizeDelete checks that the principal of ctx may delete the comment id
+// with policy and returns the policy to run. An author without the permission
+// policy needs may remove their own leaf, which is run as reparent: should a
+// reply arrive meanwhile it moves up instead of being deleted with the leaf.
+func (s *CommentsService) authorizeDelete(ctx context.Context, id int64, policy models.DeletePolicy) (models.DeletePolicy, error) {
+	perm := PermDeleteAnyComment
+	switch policy {
+	case models.DeletePolicyTombstone:
+		return policy, s.authorize(ctx, id, perm)
+	case models.DeletePolicyCascade:
+		perm = PermPurge
+	}
+
+	allowed, err := s.access.can(ctx, perm)
+	if err != nil {
+		return "", err
+	}
+	if allowed {
+		return policy, nil
+	}
+
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+
+	com, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get comment")
+		return "", err
+	}
+	if com.AuthorID == nil || *com.AuthorID != p.AuthorID || com.ReplyCount > 0 {
+		return "", ErrForbidden
+	}
+
+	return models.DeletePolicyReparent, nil
+}
+
+// actor is actorID for writes, which reference the authors row of the
+// principal: a principal without one fails with ErrProfileRequired.
+func (s *CommentsService) actor(ctx context.Context) (*int64, error) {
+	id := actorID(ctx)
+	if id == nil {
+		return nil, nil
+	}
+
+	if _, err := s.authors.GetByID(ctx, *id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProfileRequired
+		}
+		s.log.Error().
+			Err(err).
+			Int64("author_id", *id).
+			Msg("failed to get author")
+		return nil, err
+	}
+	return id, nil
+}
+
+// actorID is the author acting in ctx, nil for anonymous requests.
+func actorID(ctx context.Context) *int64 {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &p.AuthorID
+}
+
+// viewRevisions lets the history of a deleted comment through only to those
+// who may see deleted content, it holds the text the deletion removed.
+func (s *CommentsService) viewRevisions(ctx context.Context, commentID int64) error {
+	com, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", commentID).
+			Msg("failed to get comment")
+		return err
+	}
+
+	if com.DeletedAt != nil {
+		return s.access.require(ctx, PermViewDeleted)
+	}
+	return nil
+}
+
+func (s *CommentsService) GetRevisions(ctx context.Context, commentID int64) ([]*models.Revision, error) {
+	if err := s.viewRevisions(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	revs, err := s.repo.GetRevisions(ctx, commentID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", commentID).
+			Msg("failed to get comment revisions")
+		return nil, err
+	}
+	return revs, nil
+}
+
+func (s *CommentsService) GetRevision(ctx context.Context, commentID int64, rev int) (*models.Revision, error) {
+	if err := s.viewRevisions(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	res, err := s.repo.GetRevision(ctx, commentID, rev)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", commentID).
+			Int("rev", rev).
+			Msg("failed to get comment revision")
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetLocked closes a comment and its whole subtree for new replies, or opens it again.
+func (s *CommentsService) SetLocked(ctx context.Context, id int64, locked bool) error {
+	if err := s.access.require(ctx, PermLockThreads); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetLocked(ctx, id, locked); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Bool("locked", locked).
+			Msg("failed to change comment lock")
+		return err
+	}
+	return nil
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"comment-tree/internal/auth"
+	"comment-tree/internal/config"
 	"comment-tree/internal/mocks"
 	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
 	"comment-tree/internal/service"
 
 	"github.com/stretchr/testify/require"
@@ -14,26 +18,49 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
-func newTestService(t *testing.T) (*service.CommentsService, *mocks.MockCommentsRepository, context.Context) {
+// testConfig is the configuration most tests run the service with.
+var testConfig = config.Comments{
+	DeletePolicy: string(models.DeletePolicyTombstone),
+	MaxDepth:     2,
+}
+
+type serviceMocks struct {
+	repo    *mocks.MockCommentsRepository
+	threads *mocks.MockThreadsRepository
+	authors *mocks.MockAuthorsRepository
+}
+
+// newTestService builds the comments service with cfg over fresh repository mocks.
+func newTestService(t *testing.T, cfg config.Comments) (*service.CommentsService, serviceMocks, context.Context) {
 	t.Helper()
 
 	ctrl := gomock.NewController(t)
 	t.Cleanup(ctrl.Finish)
 
-	repo := mocks.NewMockCommentsRepository(ctrl)
+	m := serviceMocks{
+		repo:    mocks.NewMockCommentsRepository(ctrl),
+		threads: mocks.NewMockThreadsRepository(ctrl),
+		authors: mocks.NewMockAuthorsRepository(ctrl),
+	}
 	log := &zlog.Zerolog{}
-	svc := service.NewCommentsService(repo, log)
+	svc := service.NewCommentsService(m.repo, m.threads, m.authors, cfg, log)
 
-	return svc, repo, context.Background()
+	return svc, m, context.Background()
 }
 
 func TestCommentsService_Create(t *testing.T) {
+	threadID := int64(7)
+	now := time.Now()
+
 	t.Run("success", func(t *testing.T) {
-		svc, repo, ctx := newTestService(t)
+		svc, m, ctx := newTestService(t, testConfig)
 
-		com := &models.Comment{Content: "test"}
+		com := &models.Comment{ThreadID: threadID, Content: "test"}
 
-		repo.EXPECT().
+		m.threads.EXPECT().
+			GetByID(ctx, threadID).
+			Return(&models.Thread{ID: threadID}, nil)
+		m.repo.EXPECT().
 			Create(ctx, com).
 			Return(nil)
 
@@ -42,110 +69,898 @@ func TestCommentsService_Create(t *testing.T) {
 	})
 
 	t.Run("repo error", func(t *testing.T) {
-		svc, repo, ctx := newTestService(t)
+		svc, m, ctx := newTestService(t, testConfig)
 
-		com := &models.Comment{Content: "test"}
+		com := &models.Comment{ThreadID: threadID, Content: "test"}
 		expErr := errors.New("db error")
 
-		repo.EXPECT().
+		m.threads.EXPECT().
+			GetByID(ctx, threadID).
+			Return(&models.Thread{ID: threadID}, nil)
+		m.repo.EXPECT().
 			Create(ctx, com).
 			Return(expErr)
 
 		err := svc.Create(ctx, com)
 		require.ErrorIs(t, err, expErr)
 	})
+
+	t.Run("author from principal", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 42, models.RoleUser)
+
+		forged := int64(1)
+		com := &models.Comment{ThreadID: threadID, AuthorID: &forged, Content: "test"}
+
+		m.threads.EXPECT().
+			GetByID(ctx, threadID).
+			Return(&models.Thread{ID: threadID}, nil)
+		m.repo.EXPECT().
+			Create(ctx, com).
+			Return(nil)
+
+		require.NoError(t, svc.Create(ctx, com))
+		require.Equal(t, int64(42), *com.AuthorID)
+	})
+
+	t.Run("principal without profile", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 42})
+
+		m.authors.EXPECT().
+			GetByID(ctx, int64(42)).
+			Return(nil, repository.ErrNotFound)
+
+		err := svc.Create(ctx, &models.Comment{ThreadID: threadID, Content: "test"})
+		require.ErrorIs(t, err, service.ErrProfileRequired)
+	})
+
+	tests := []struct {
+		name      string
+		threadID  int64
+		thread    *models.Thread
+		threadErr error
+		reason    string
+	}{
+		{
+			name:   "no thread",
+			reason: "thread_id is required",
+		},
+		{
+			name:      "missing thread",
+			threadID:  threadID,
+			threadErr: repository.ErrNotFound,
+			reason:    "thread does not exist",
+		},
+		{
+			name:     "locked thread",
+			threadID: threadID,
+			thread:   &models.Thread{ID: threadID, LockedAt: &now},
+			reason:   "thread is locked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m, ctx := newTestService(t, testConfig)
+
+			if tt.threadID != 0 {
+				m.threads.EXPECT().
+					GetByID(ctx, tt.threadID).
+					Return(tt.thread, tt.threadErr)
+			}
+
+			err := svc.Create(ctx, &models.Comment{ThreadID: tt.threadID, Content: "test"})
+
+			var vErr *service.ValidationError
+			require.ErrorAs(t, err, &vErr)
+			require.Equal(t, "thread_id", vErr.Field)
+			require.Equal(t, tt.reason, vErr.Reason)
+		})
+	}
+}
+
+func TestCommentsService_Create_Reply(t *testing.T) {
+	parentID := int64(5)
+	now := time.Now()
+
+	threadID := int64(7)
+
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		com := &models.Comment{ParentID: &parentID, Content: "reply"}
+
+		m.repo.EXPECT().
+			GetByID(ctx, parentID).
+			Return(&models.Comment{ID: parentID, ThreadID: threadID}, nil)
+		m.repo.EXPECT().
+			GetAncestors(ctx, parentID).
+			Return([]*models.Comment{{ID: 1}}, nil)
+		m.threads.EXPECT().
+			GetByID(ctx, threadID).
+			Return(&models.Thread{ID: threadID}, nil)
+		m.repo.EXPECT().
+			Create(ctx, com).
+			Return(nil)
+
+		err := svc.Create(ctx, com)
+		require.NoError(t, err)
+		require.Equal(t, threadID, com.ThreadID)
+	})
+
+	t.Run("other thread", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		m.repo.EXPECT().
+			GetByID(ctx, parentID).
+			Return(&models.Comment{ID: parentID, ThreadID: threadID}, nil)
+
+		err := svc.Create(ctx, &models.Comment{ParentID: &parentID, ThreadID: threadID + 1, Content: "reply"})
+
+		var vErr *service.ValidationError
+		require.ErrorAs(t, err, &vErr)
+		require.Equal(t, "thread_id", vErr.Field)
+	})
+
+	tests := []struct {
+		name      string
+		parent    *models.Comment
+		parentErr error
+		ancestors []*models.Comment
+		reason    string
+	}{
+		{
+			name:      "missing parent",
+			parentErr: repository.ErrNotFound,
+			reason:    "parent comment does not exist",
+		},
+		{
+			name:   "deleted parent",
+			parent: &models.Comment{ID: parentID, DeletedAt: &now},
+			reason: "parent comment is deleted",
+		},
+		{
+			name:      "locked ancestor",
+			parent:    &models.Comment{ID: parentID},
+			ancestors: []*models.Comment{{ID: 1, LockedAt: &now}},
+			reason:    "thread is locked",
+		},
+		{
+			name:      "too deep",
+			parent:    &models.Comment{ID: parentID},
+			ancestors: []*models.Comment{{ID: 1}, {ID: 2}},
+			reason:    "maximum nesting depth of 2 reached",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m, ctx := newTestService(t, testConfig)
+
+			m.repo.EXPECT().
+				GetByID(ctx, parentID).
+				Return(tt.parent, tt.parentErr)
+			if tt.parent != nil && tt.parent.DeletedAt == nil {
+				m.repo.EXPECT().
+					GetAncestors(ctx, parentID).
+					Return(tt.ancestors, nil)
+			}
+
+			err := svc.Create(ctx, &models.Comment{ParentID: &parentID, Content: "reply"})
+			require.ErrorIs(t, err, service.ErrValidation)
+
+			var vErr *service.ValidationError
+			require.ErrorAs(t, err, &vErr)
+			require.Equal(t, "parent_id", vErr.Field)
+			require.Equal(t, tt.reason, vErr.Reason)
+		})
+	}
+}
+
+// asRole makes ctx carry the principal id whose author row has role.
+func asRole(ctx context.Context, m serviceMocks, id int64, role models.Role) context.Context {
+	ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: id})
+	m.authors.EXPECT().
+		GetByID(ctx, id).
+		Return(&models.Author{ID: id, Role: role}, nil).
+		AnyTimes()
+	return ctx
 }
 
 func TestCommentsService_Update(t *testing.T) {
-	svc, repo, ctx := newTestService(t)
+	authorID := int64(42)
 
-	com := &models.Comment{ID: 1, Content: "updated"}
+	t.Run("author", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, authorID, models.RoleUser)
 
-	repo.EXPECT().
-		Update(ctx, com).
-		Return(nil)
+		com := &models.Comment{ID: 1, Content: "updated"}
 
-	err := svc.Update(ctx, com)
-	require.NoError(t, err)
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, AuthorID: &authorID}, nil)
+		m.repo.EXPECT().
+			Update(ctx, com).
+			Return(nil)
+
+		err := svc.Update(ctx, com)
+		require.NoError(t, err)
+	})
+
+	t.Run("moderator", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 99, models.RoleModerator)
+
+		com := &models.Comment{ID: 1, Content: "updated"}
+
+		m.repo.EXPECT().
+			Update(ctx, com).
+			Return(nil)
+
+		err := svc.Update(ctx, com)
+		require.NoError(t, err)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testConfig)
+
+		err := svc.Update(ctx, &models.Comment{ID: 1, Content: "updated"})
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+
+	t.Run("someone else", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, authorID+1, models.RoleUser)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, AuthorID: &authorID}, nil)
+
+		err := svc.Update(ctx, &models.Comment{ID: 1, Content: "updated"})
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("anonymous comment", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, authorID, models.RoleUser)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1}, nil)
+
+		err := svc.Update(ctx, &models.Comment{ID: 1, Content: "updated"})
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
 }
 
 func TestCommentsService_Delete(t *testing.T) {
+	moderator := int64(99)
+
 	t.Run("success", func(t *testing.T) {
-		svc, repo, ctx := newTestService(t)
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, moderator, models.RoleModerator)
 
-		repo.EXPECT().
-			Delete(ctx, int64(1)).
-			Return(nil)
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyReparent, &moderator).
+			Return(int64(3), nil)
+
+		res, err := svc.Delete(ctx, 1, models.DeletePolicyReparent)
+		require.NoError(t, err)
+		require.Equal(t, &models.DeleteResult{
+			ID:       1,
+			Policy:   models.DeletePolicyReparent,
+			Affected: 3,
+		}, res)
+	})
+
+	t.Run("default policy", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, moderator, models.RoleAdmin)
+
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyTombstone, &moderator).
+			Return(int64(0), nil)
+
+		res, err := svc.Delete(ctx, 1, "")
+		require.NoError(t, err)
+		require.Equal(t, models.DeletePolicyTombstone, res.Policy)
+	})
+
+	t.Run("author", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		author := int64(42)
+		ctx = asRole(ctx, m, author, models.RoleUser)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, AuthorID: &author}, nil)
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyTombstone, &author).
+			Return(int64(0), nil)
+
+		_, err := svc.Delete(ctx, 1, models.DeletePolicyTombstone)
+		require.NoError(t, err)
+	})
 
-		err := svc.Delete(ctx, 1)
+	t.Run("admin cascade", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, moderator, models.RoleAdmin)
+
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyCascade, &moderator).
+			Return(int64(4), nil)
+
+		res, err := svc.Delete(ctx, 1, models.DeletePolicyCascade)
 		require.NoError(t, err)
+		require.EqualValues(t, 4, res.Affected)
+	})
+
+	t.Run("author leaf", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		author := int64(42)
+		ctx = asRole(ctx, m, author, models.RoleUser)
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, AuthorID: &author}, nil)
+		// a reply arriving meanwhile moves up instead of being deleted
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyReparent, &author).
+			Return(int64(0), nil)
+
+		res, err := svc.Delete(ctx, 1, models.DeletePolicyCascade)
+		require.NoError(t, err)
+		require.Equal(t, models.DeletePolicyCascade, res.Policy)
+	})
+
+	t.Run("no default policy configured", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, config.Comments{})
+		ctx = asRole(ctx, m, moderator, models.RoleModerator)
+
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyTombstone, &moderator).
+			Return(int64(0), nil)
+
+		res, err := svc.Delete(ctx, 1, "")
+		require.NoError(t, err)
+		require.Equal(t, models.DeletePolicyTombstone, res.Policy)
+	})
+
+	// removing the replies of others is beyond the author of a comment
+	policyTests := []struct {
+		name   string
+		role   models.Role
+		policy models.DeletePolicy
+	}{
+		{name: "author cascade", role: models.RoleUser, policy: models.DeletePolicyCascade},
+		{name: "author reparent", role: models.RoleUser, policy: models.DeletePolicyReparent},
+		{name: "moderator cascade", role: models.RoleModerator, policy: models.DeletePolicyCascade},
+	}
+	for _, tt := range policyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m, ctx := newTestService(t, testConfig)
+
+			author := int64(42)
+			ctx = asRole(ctx, m, author, tt.role)
+			m.repo.EXPECT().
+				GetByID(ctx, int64(1)).
+				Return(&models.Comment{ID: 1, AuthorID: &author, ReplyCount: 2}, nil).
+				AnyTimes()
+
+			res, err := svc.Delete(ctx, 1, tt.policy)
+			require.Nil(t, res)
+			require.ErrorIs(t, err, service.ErrForbidden)
+		})
+	}
+
+	t.Run("someone else", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 7, models.RoleUser)
+
+		author := int64(42)
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, AuthorID: &author}, nil)
+
+		res, err := svc.Delete(ctx, 1, models.DeletePolicyTombstone)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testConfig)
+
+		res, err := svc.Delete(ctx, 1, models.DeletePolicyTombstone)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testConfig)
+
+		res, err := svc.Delete(ctx, 1, "shred")
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrInvalidDeletePolicy)
 	})
 
 	t.Run("repo error", func(t *testing.T) {
-		svc, repo, ctx := newTestService(t)
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, moderator, models.RoleAdmin)
 
 		expErr := errors.New("delete failed")
 
-		repo.EXPECT().
-			Delete(ctx, int64(1)).
-			Return(expErr)
+		m.repo.EXPECT().
+			Delete(ctx, int64(1), models.DeletePolicyCascade, &moderator).
+			Return(int64(0), expErr)
 
-		err := svc.Delete(ctx, 1)
+		res, err := svc.Delete(ctx, 1, models.DeletePolicyCascade)
+		require.Nil(t, res)
 		require.ErrorIs(t, err, expErr)
 	})
 }
 
 func TestCommentsService_GetByParent(t *testing.T) {
-	svc, repo, ctx := newTestService(t)
+	svc, m, ctx := newTestService(t, testConfig)
 
 	parentID := int64(10)
-	limit, offset := int64(20), int64(0)
+	page := models.Page{Limit: 20}
 
-	expected := []*models.Comment{
-		{ID: 1, ParentID: &parentID, Content: "c1"},
-		{ID: 2, ParentID: &parentID, Content: "c2"},
+	expected := &models.CommentsPage{
+		Items: []*models.Comment{
+			{ID: 1, ParentID: &parentID, Content: "c1"},
+			{ID: 2, ParentID: &parentID, Content: "c2"},
+		},
 	}
 
-	repo.EXPECT().
-		GetByParent(ctx, &parentID, limit, offset).
+	q := models.CommentsQuery{ParentID: &parentID, Sort: models.SortOld, Page: page}
+
+	m.repo.EXPECT().
+		GetByParent(ctx, q).
 		Return(expected, nil)
 
-	res, err := svc.GetByParent(ctx, &parentID, limit, offset)
+	res, err := svc.GetByParent(ctx, q)
 	require.NoError(t, err)
 	require.Equal(t, expected, res)
+
+	t.Run("roots without thread", func(t *testing.T) {
+		res, err := svc.GetByParent(ctx, models.CommentsQuery{Page: page})
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrThreadRequired)
+	})
+}
+
+func TestCommentsService_GetByParent_Sort(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		m.repo.EXPECT().
+			GetByParent(ctx, models.CommentsQuery{ThreadID: 1, Sort: models.SortOld}).
+			Return(&models.CommentsPage{}, nil)
+
+		_, err := svc.GetByParent(ctx, models.CommentsQuery{ThreadID: 1})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testConfig)
+
+		_, err := svc.GetByParent(ctx, models.CommentsQuery{ThreadID: 1, Sort: "best"})
+		require.ErrorIs(t, err, models.ErrInvalidSort)
+	})
+
+	cursors := []struct {
+		name   string
+		sort   models.Sort
+		cursor models.Cursor
+		valid  bool
+	}{
+		{name: "same sort", sort: models.SortHot, cursor: models.Cursor{ID: 1, Sort: models.SortHot}, valid: true},
+		{name: "legacy cursor", sort: models.SortOld, cursor: models.Cursor{ID: 1}, valid: true},
+		{name: "other sort", sort: models.SortTop, cursor: models.Cursor{ID: 1, Sort: models.SortNew}},
+		{name: "legacy cursor with sort", sort: models.SortNew, cursor: models.Cursor{ID: 1}},
+	}
+
+	for _, tc := range cursors {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, m, ctx := newTestService(t, testConfig)
+
+			q := models.CommentsQuery{ThreadID: 1, Sort: tc.sort, Page: models.Page{Limit: 10, Cursor: &tc.cursor}}
+			if tc.valid {
+				m.repo.EXPECT().
+					GetByParent(ctx, q).
+					Return(&models.CommentsPage{}, nil)
+			}
+
+			_, err := svc.GetByParent(ctx, q)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, models.ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestCommentsService_GetByParent_Tombstones(t *testing.T) {
+	svc, m, ctx := newTestService(t, testConfig)
+
+	deletedAt := time.Now()
+	q := models.CommentsQuery{ThreadID: 1, Sort: models.SortOld, Page: models.Page{Limit: 10}}
+
+	m.repo.EXPECT().
+		GetByParent(ctx, q).
+		Return(&models.CommentsPage{
+			Items: []*models.Comment{
+				{ID: 1, Content: "kept"},
+				{ID: 2, Content: "bad post", DeletedAt: &deletedAt},
+			},
+		}, nil)
+
+	res, err := svc.GetByParent(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, res.Items, 2)
+	require.Equal(t, "kept", res.Items[0].Content)
+	require.Equal(t, models.DeletedContent, res.Items[1].Content)
+}
+
+func TestCommentsService_GetByParent_ViewDeleted(t *testing.T) {
+	svc, m, ctx := newTestService(t, testConfig)
+	ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+	deletedAt := time.Now()
+	q := models.CommentsQuery{ThreadID: 1, Sort: models.SortOld, Page: models.Page{Limit: 10}}
+
+	m.repo.EXPECT().
+		GetByParent(ctx, q).
+		Return(&models.CommentsPage{
+			Items: []*models.Comment{{ID: 2, Content: "bad post", DeletedAt: &deletedAt}},
+		}, nil)
+	m.repo.EXPECT().
+		GetVotes(ctx, int64(1), []int64{2}).
+		Return(map[int64]int{}, nil)
+
+	res, err := svc.GetByParent(ctx, q)
+	require.NoError(t, err)
+	require.Equal(t, "bad post", res.Items[0].Content)
+}
+
+func TestCommentsService_GetByParent_Authors(t *testing.T) {
+	svc, m, ctx := newTestService(t, testConfig)
+
+	alice, bob := int64(1), int64(2)
+	deletedAt := time.Now()
+	q := models.CommentsQuery{ThreadID: 1, Sort: models.SortOld, Page: models.Page{Limit: 10}}
+
+	m.repo.EXPECT().
+		GetByParent(ctx, q).
+		Return(&models.CommentsPage{
+			Items: []*models.Comment{
+				{ID: 1, AuthorID: &alice, Content: "first"},
+				{ID: 2, AuthorID: &alice, Content: "second"},
+				{ID: 3, AuthorID: &bob, Content: "gone", DeletedAt: &deletedAt},
+				{ID: 4, Content: "anonymous"},
+			},
+		}, nil)
+	m.authors.EXPECT().
+		GetByIDs(ctx, []int64{alice}).
+		Return([]*models.Author{{ID: alice, DisplayName: "Alice"}}, nil)
+
+	res, err := svc.GetByParent(ctx, q)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", res.Items[0].Author.DisplayName)
+	require.Same(t, res.Items[0].Author, res.Items[1].Author)
+	require.Nil(t, res.Items[2].Author)
+	require.Nil(t, res.Items[2].AuthorID)
+	require.Nil(t, res.Items[3].Author)
 }
 
 func TestCommentsService_Search(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		svc, repo, ctx := newTestService(t)
+		svc, m, ctx := newTestService(t, testConfig)
 
-		query := "hello"
-		limit, offset := int64(10), int64(0)
+		q := models.SearchQuery{
+			Query:    "hello",
+			ThreadID: 1,
+			Page:     models.Page{Limit: 10, Cursor: &models.Cursor{Rank: 0.5, ID: 3}},
+		}
 
-		expected := []*models.Comment{
-			{ID: 1, Content: "hello world"},
+		expected := &models.SearchPage{
+			Items: []*models.SearchResult{
+				{Comment: &models.Comment{ID: 1, Content: "hello world"}, Highlight: "<mark>hello</mark> world", Rank: 0.1},
+			},
 		}
 
-		repo.EXPECT().
-			Search(ctx, query, limit, offset).
+		want := q
+		want.Langs = []string{models.DefaultSearchConfig}
+		want.Highlight = testHighlight
+		m.repo.EXPECT().
+			Search(ctx, want).
 			Return(expected, nil)
 
-		res, err := svc.Search(ctx, query, limit, offset)
+		res, err := svc.Search(ctx, q)
 		require.NoError(t, err)
 		require.Equal(t, expected, res)
 	})
 
 	t.Run("repo error", func(t *testing.T) {
-		svc, repo, ctx := newTestService(t)
+		svc, m, ctx := newTestService(t, testConfig)
 
 		expErr := errors.New("search failed")
 
-		repo.EXPECT().
-			Search(ctx, "q", int64(5), int64(0)).
+		q := models.SearchQuery{
+			Query: "q", Langs: []string{models.DefaultSearchConfig}, Highlight: testHighlight, Page: models.Page{Limit: 5},
+		}
+
+		m.repo.EXPECT().
+			Search(ctx, q).
+			Return(nil, expErr)
+
+		res, err := svc.Search(ctx, q)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestCommentsService_GetSubtree(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		expected := &models.CommentNode{
+			Comment: models.Comment{ID: 1, Content: "root"},
+			Children: []*models.CommentNode{
+				{Comment: models.Comment{ID: 2, Content: "reply"}, Depth: 1},
+			},
+		}
+
+		m.repo.EXPECT().
+			GetSubtree(ctx, int64(1), int64(3), int64(10)).
+			Return(expected, nil)
+
+		res, err := svc.GetSubtree(ctx, 1, 3, 10)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		expErr := errors.New("tree failed")
+
+		m.repo.EXPECT().
+			GetSubtree(ctx, int64(1), int64(3), int64(10)).
 			Return(nil, expErr)
 
-		res, err := svc.Search(ctx, "q", 5, 0)
+		res, err := svc.GetSubtree(ctx, 1, 3, 10)
 		require.Nil(t, res)
 		require.ErrorIs(t, err, expErr)
 	})
 }
+
+func TestCommentsService_GetPermalink(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		rootID, parentID := int64(1), int64(2)
+		deletedAt := time.Now()
+		ancestors := []*models.Comment{
+			{ID: rootID, Content: "root"},
+			{ID: parentID, ParentID: &rootID, Content: "gone", DeletedAt: &deletedAt},
+		}
+		tree := &models.CommentNode{
+			Comment: models.Comment{ID: 3, ParentID: &parentID, Content: "linked"},
+			Children: []*models.CommentNode{
+				{Comment: models.Comment{ID: 4, Content: "reply"}, Depth: 1},
+			},
+		}
+
+		m.repo.EXPECT().
+			GetSubtree(ctx, int64(3), int64(1), int64(10)).
+			Return(tree, nil)
+		m.repo.EXPECT().
+			GetAncestors(ctx, int64(3)).
+			Return(ancestors, nil)
+
+		res, err := svc.GetPermalink(ctx, 3, 1, 10)
+		require.NoError(t, err)
+		require.Equal(t, &models.Permalink{Ancestors: ancestors, Comment: tree}, res)
+		require.Equal(t, models.DeletedContent, res.Ancestors[1].Content)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		m.repo.EXPECT().
+			GetSubtree(ctx, int64(3), int64(0), int64(10)).
+			Return(nil, repository.ErrNotFound)
+
+		res, err := svc.GetPermalink(ctx, 3, 0, 10)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsService_Purge(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleAdmin)
+
+		m.repo.EXPECT().
+			Purge(ctx, int64(1)).
+			Return(nil)
+
+		err := svc.Purge(ctx, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("moderator", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+		err := svc.Purge(ctx, 1)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleAdmin)
+
+		expErr := errors.New("purge failed")
+
+		m.repo.EXPECT().
+			Purge(ctx, int64(1)).
+			Return(expErr)
+
+		err := svc.Purge(ctx, 1)
+		require.ErrorIs(t, err, expErr)
+	})
+}
+
+func TestCommentsService_GetRevisions(t *testing.T) {
+	expected := []*models.Revision{
+		{CommentID: 1, Rev: 1, Content: "first"},
+		{CommentID: 1, Rev: 2, Content: "second"},
+	}
+	deletedAt := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1}, nil)
+		m.repo.EXPECT().
+			GetRevisions(ctx, int64(1)).
+			Return(expected, nil)
+
+		res, err := svc.GetRevisions(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("deleted comment", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 7, models.RoleUser)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, DeletedAt: &deletedAt}, nil)
+
+		res, err := svc.GetRevisions(ctx, 1)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("deleted comment anonymous", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, DeletedAt: &deletedAt}, nil)
+
+		res, err := svc.GetRevisions(ctx, 1)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+
+	t.Run("deleted comment moderator", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 99, models.RoleModerator)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, DeletedAt: &deletedAt}, nil)
+		m.repo.EXPECT().
+			GetRevisions(ctx, int64(1)).
+			Return(expected, nil)
+
+		res, err := svc.GetRevisions(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(nil, repository.ErrNotFound)
+
+		res, err := svc.GetRevisions(ctx, 1)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsService_GetRevision(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		expected := &models.Revision{CommentID: 1, Rev: 2, Content: "second"}
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1}, nil)
+		m.repo.EXPECT().
+			GetRevision(ctx, int64(1), 2).
+			Return(expected, nil)
+
+		res, err := svc.GetRevision(ctx, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+
+		expErr := errors.New("not found")
+
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1}, nil)
+		m.repo.EXPECT().
+			GetRevision(ctx, int64(1), 5).
+			Return(nil, expErr)
+
+		res, err := svc.GetRevision(ctx, 1, 5)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, expErr)
+	})
+
+	t.Run("deleted comment", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 7, models.RoleUser)
+
+		deletedAt := time.Now()
+		m.repo.EXPECT().
+			GetByID(ctx, int64(1)).
+			Return(&models.Comment{ID: 1, DeletedAt: &deletedAt}, nil)
+
+		res, err := svc.GetRevision(ctx, 1, 2)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+}
+
+func TestCommentsService_SetLocked(t *testing.T) {
+	t.Run("moderator", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleModerator)
+
+		m.repo.EXPECT().
+			SetLocked(ctx, int64(1), true).
+			Return(nil)
+
+		err := svc.SetLocked(ctx, 1, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 1, models.RoleUser)
+
+		err := svc.SetLocked(ctx, 1, true)
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+}
@@ -0,0 +1,153 @@
+//go:generate mockgen -source=threads.go -destination=../mocks/threads_mocks.go -package=mocks
+package service
+
+import (
+	"context"
+
+	"comment-tree/internal/models"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+type ThreadsRepository interface {
+	Create(ctx context.Context, th *models.Thread) error
+	Update(ctx context.Context, th *models.Thread) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*models.Thread, error)
+	GetByKey(ctx context.Context, key string) (*models.Thread, error)
+	List(ctx context.Context, page models.Page) (*models.ThreadsPage, error)
+	SetLocked(ctx context.Context, id int64, locked bool) error
+}
+
+type ThreadsService struct {
+	repo   ThreadsRepository
+	access access
+	log    *zlog.Zerolog
+}
+
+func NewThreadsService(repo ThreadsRepository, authors AuthorsRepository, log *zlog.Zerolog) *ThreadsService {
+	return &ThreadsService{
+		repo:   repo,
+		access: access{authors: authors},
+		log:    log,
+	}
+}
+
+// Create opens a thread, which takes the same permission as managing them.
+func (s *ThreadsService) Create(ctx context.Context, th *models.Thread) error {
+	if err := s.access.require(ctx, PermManageThreads); err != nil {
+		return err
+	}
+
+	key, err := models.NormalizeThreadKey(th.Key)
+	if err != nil {
+		return err
+	}
+	th.Key = key
+
+	if err := s.repo.Create(ctx, th); err != nil {
+		s.log.Error().
+			Err(err).
+			Str("key", th.Key).
+			Msg("failed to create thread")
+		return err
+	}
+	return nil
+}
+
+func (s *ThreadsService) Update(ctx context.Context, th *models.Thread) error {
+	if err := s.access.require(ctx, PermManageThreads); err != nil {
+		return err
+	}
+
+	key, err := models.NormalizeThreadKey(th.Key)
+	if err != nil {
+		return err
+	}
+	th.Key = key
+
+	if err := s.repo.Update(ctx, th); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", th.ID).
+			Msg("failed to update thread")
+		return err
+	}
+	return nil
+}
+
+// Delete removes a thread with all of its comments, so it needs the same
+// permission as purging comments.
+func (s *ThreadsService) Delete(ctx context.Context, id int64) error {
+	if err := s.access.require(ctx, PermPurge); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to delete thread")
+		return err
+	}
+	return nil
+}
+
+func (s *ThreadsService) GetByID(ctx context.Context, id int64) (*models.Thread, error) {
+	th, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to get thread")
+		return nil, err
+	}
+	return th, nil
+}
+
+// GetByKey finds the thread of an external object. The key is normalized the
+// same way as on create, so any spelling of the same URL matches.
+func (s *ThreadsService) GetByKey(ctx context.Context, key string) (*models.Thread, error) {
+	key, err := models.NormalizeThreadKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	th, err := s.repo.GetByKey(ctx, key)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Str("key", key).
+			Msg("failed to get thread by key")
+		return nil, err
+	}
+	return th, nil
+}
+
+func (s *ThreadsService) List(ctx context.Context, page models.Page) (*models.ThreadsPage, error) {
+	threads, err := s.repo.List(ctx, page)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Msg("failed to list threads")
+		return nil, err
+	}
+	return threads, nil
+}
+
+// SetLocked closes a thread for new comments, or opens it again.
+func (s *ThreadsService) SetLocked(ctx context.Context, id int64, locked bool) error {
+	if err := s.access.require(ctx, PermLockThreads); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetLocked(ctx, id, locked); err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Bool("locked", locked).
+			Msg("failed to change thread lock")
+		return err
+	}
+	return nil
+}
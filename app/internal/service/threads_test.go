@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/mocks"
+	"comment-tree/internal/models"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/zlog"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestThreadsService(t *testing.T) (*service.ThreadsService, *mocks.MockThreadsRepository, *mocks.MockAuthorsRepository, context.Context) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	repo := mocks.NewMockThreadsRepository(ctrl)
+	authors := mocks.NewMockAuthorsRepository(ctrl)
+	svc := service.NewThreadsService(repo, authors, &zlog.Zerolog{})
+
+	return svc, repo, authors, context.Background()
+}
+
+func TestThreadsService_Create(t *testing.T) {
+	t.Run("normalizes key", func(t *testing.T) {
+		svc, repo, authors, ctx := newTestThreadsService(t)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 5})
+
+		authors.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(&models.Author{ID: 5, Role: models.RoleModerator}, nil)
+
+		th := &models.Thread{Key: "https://Example.com/post/"}
+
+		repo.EXPECT().
+			Create(ctx, th).
+			Return(nil)
+
+		err := svc.Create(ctx, th)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/post", th.Key)
+	})
+
+	t.Run("blank key", func(t *testing.T) {
+		svc, _, authors, ctx := newTestThreadsService(t)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 5})
+
+		authors.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(&models.Author{ID: 5, Role: models.RoleModerator}, nil)
+
+		err := svc.Create(ctx, &models.Thread{Key: " "})
+		require.ErrorIs(t, err, models.ErrInvalidThreadKey)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		svc, _, authors, ctx := newTestThreadsService(t)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 5})
+
+		authors.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(&models.Author{ID: 5, Role: models.RoleUser}, nil)
+
+		err := svc.Create(ctx, &models.Thread{Key: "article:1"})
+		require.ErrorIs(t, err, service.ErrForbidden)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		svc, _, _, ctx := newTestThreadsService(t)
+
+		err := svc.Create(ctx, &models.Thread{Key: "article:1"})
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+}
+
+func TestThreadsService_GetByKey(t *testing.T) {
+	svc, repo, _, ctx := newTestThreadsService(t)
+
+	expected := &models.Thread{ID: 3, Key: "http://example.com/a?x=1&y=2"}
+
+	repo.EXPECT().
+		GetByKey(ctx, expected.Key).
+		Return(expected, nil)
+
+	res, err := svc.GetByKey(ctx, "HTTP://example.com:80/a/?y=2&x=1")
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+}
+
+func TestThreadsService_SetLocked(t *testing.T) {
+	t.Run("moderator", func(t *testing.T) {
+		svc, repo, authors, ctx := newTestThreadsService(t)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 5})
+
+		authors.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(&models.Author{ID: 5, Role: models.RoleModerator}, nil)
+		repo.EXPECT().
+			SetLocked(ctx, int64(3), true).
+			Return(nil)
+
+		require.NoError(t, svc.SetLocked(ctx, 3, true))
+	})
+
+	t.Run("user", func(t *testing.T) {
+		svc, _, authors, ctx := newTestThreadsService(t)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 5})
+
+		authors.EXPECT().
+			GetByID(ctx, int64(5)).
+			Return(&models.Author{ID: 5, Role: models.RoleUser}, nil)
+
+		require.ErrorIs(t, svc.SetLocked(ctx, 3, true), service.ErrForbidden)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		svc, _, _, ctx := newTestThreadsService(t)
+
+		require.ErrorIs(t, svc.SetLocked(ctx, 3, true), service.ErrUnauthenticated)
+	})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/models"
+)
+
+// Vote records the vote of the principal of ctx on a comment. Voting again
+// replaces the earlier vote.
+func (s *CommentsService) Vote(ctx context.Context, id int64, value int) (*models.VoteResult, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if value != 1 && value != -1 {
+		return nil, &ValidationError{Field: "value", Reason: "vote must be 1 or -1"}
+	}
+	if _, err := s.actor(ctx); err != nil {
+		return nil, err
+	}
+
+	score, err := s.repo.Vote(ctx, id, p.AuthorID, value)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to vote on comment")
+		return nil, err
+	}
+
+	return &models.VoteResult{CommentID: id, Score: score, MyVote: value}, nil
+}
+
+// Unvote takes back the vote of the principal of ctx.
+func (s *CommentsService) Unvote(ctx context.Context, id int64) (*models.VoteResult, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	score, err := s.repo.Unvote(ctx, id, p.AuthorID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("id", id).
+			Msg("failed to take back vote")
+		return nil, err
+	}
+
+	return &models.VoteResult{CommentID: id, Score: score}, nil
+}
+
+// attachVotes fills in MyVote for the principal of ctx.
+func (s *CommentsService) attachVotes(ctx context.Context, coms []*models.Comment) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok || len(coms) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(coms))
+	for _, com := range coms {
+		ids = append(ids, com.ID)
+	}
+
+	votes, err := s.repo.GetVotes(ctx, p.AuthorID, ids)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Msg("failed to load votes")
+		return err
+	}
+
+	for _, com := range coms {
+		com.MyVote = votes[com.ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"testing"
+
+	"comment-tree/internal/auth"
+	"comment-tree/internal/models"
+	"comment-tree/internal/repository"
+	"comment-tree/internal/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsService_Vote(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 7, models.RoleUser)
+
+		m.repo.EXPECT().
+			Vote(ctx, int64(1), int64(7), -1).
+			Return(-3, nil)
+
+		res, err := svc.Vote(ctx, 1, -1)
+		require.NoError(t, err)
+		require.Equal(t, &models.VoteResult{CommentID: 1, Score: -3, MyVote: -1}, res)
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 7})
+
+		m.authors.EXPECT().
+			GetByID(ctx, int64(7)).
+			Return(nil, repository.ErrNotFound)
+
+		_, err := svc.Vote(ctx, 1, 1)
+		require.ErrorIs(t, err, service.ErrProfileRequired)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testConfig)
+
+		res, err := svc.Vote(ctx, 1, 1)
+		require.Nil(t, res)
+		require.ErrorIs(t, err, service.ErrUnauthenticated)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		svc, _, ctx := newTestService(t, testConfig)
+		ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 7})
+
+		_, err := svc.Vote(ctx, 1, 2)
+		require.ErrorIs(t, err, service.ErrValidation)
+	})
+
+	t.Run("deleted comment", func(t *testing.T) {
+		svc, m, ctx := newTestService(t, testConfig)
+		ctx = asRole(ctx, m, 7, models.RoleUser)
+
+		m.repo.EXPECT().
+			Vote(ctx, int64(1), int64(7), 1).
+			Return(0, repository.ErrNotFound)
+
+		_, err := svc.Vote(ctx, 1, 1)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCommentsService_Unvote(t *testing.T) {
+	svc, m, ctx := newTestService(t, testConfig)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{AuthorID: 7})
+
+	m.repo.EXPECT().
+		Unvote(ctx, int64(1), int64(7)).
+		Return(4, nil)
+
+	res, err := svc.Unvote(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, &models.VoteResult{CommentID: 1, Score: 4}, res)
+}
+
+func TestCommentsService_GetPermalink_MyVote(t *testing.T) {
+	svc, m, ctx := newTestService(t, testConfig)
+	ctx = asRole(ctx, m, 7, models.RoleUser)
+
+	m.repo.EXPECT().
+		GetSubtree(ctx, int64(1), int64(0), int64(10)).
+		Return(&models.CommentNode{Comment: models.Comment{ID: 1, Content: "hi", Score: 5}}, nil)
+	m.repo.EXPECT().
+		GetAncestors(ctx, int64(1)).
+		Return([]*models.Comment{}, nil)
+	m.repo.EXPECT().
+		GetVotes(ctx, int64(7), []int64{1}).
+		Return(map[int64]int{1: 1}, nil)
+
+	res, err := svc.GetPermalink(ctx, 1, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, 5, res.Comment.Score)
+	require.Equal(t, 1, res.Comment.MyVote)
+}
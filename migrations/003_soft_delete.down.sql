@@ -0,0 +1,8 @@
+ALTER TABLE comments DROP CONSTRAINT comments_parent_id_fkey;
+ALTER TABLE comments
+    ADD CONSTRAINT comments_parent_id_fkey
+    FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE;
+
+ALTER TABLE comments
+    DROP COLUMN deleted_at,
+    DROP COLUMN deleted_by;
@@ -0,0 +1,9 @@
+ALTER TABLE comments
+    ADD COLUMN deleted_at TIMESTAMPTZ,
+    ADD COLUMN deleted_by BIGINT;
+
+-- replies must outlive a deleted parent, subtrees are only removed by an explicit purge
+ALTER TABLE comments DROP CONSTRAINT comments_parent_id_fkey;
+ALTER TABLE comments
+    ADD CONSTRAINT comments_parent_id_fkey
+    FOREIGN KEY (parent_id) REFERENCES comments(id);
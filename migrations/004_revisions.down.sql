@@ -0,0 +1,5 @@
+DROP TABLE comment_revisions;
+
+ALTER TABLE comments
+    DROP COLUMN updated_at,
+    DROP COLUMN edit_count;
@@ -0,0 +1,13 @@
+ALTER TABLE comments
+    ADD COLUMN updated_at TIMESTAMPTZ,
+    ADD COLUMN edit_count INTEGER NOT NULL DEFAULT 0;
+
+-- rev n keeps the content that the n-th edit of the comment replaced
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    comment_id BIGINT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    rev INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (comment_id, rev)
+);
@@ -0,0 +1,3 @@
+-- a locked comment closes its whole subtree for new replies
+ALTER TABLE comments
+    ADD COLUMN locked_at TIMESTAMPTZ;
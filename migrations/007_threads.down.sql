@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_comments_thread_id;
+DROP INDEX IF EXISTS idx_comments_thread_roots;
+
+ALTER TABLE comments
+    DROP COLUMN thread_id;
+
+DROP TABLE IF EXISTS threads;
@@ -0,0 +1,26 @@
+-- a thread groups the comment tree of one external object, e.g. an article
+-- or a product page, identified by its normalized key
+CREATE TABLE IF NOT EXISTS threads (
+    id BIGSERIAL PRIMARY KEY,
+    key TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+-- comments written before threads existed move to a default thread
+INSERT INTO threads (key, title) VALUES ('default', 'Default thread');
+
+ALTER TABLE comments
+    ADD COLUMN thread_id BIGINT REFERENCES threads(id) ON DELETE CASCADE;
+
+UPDATE comments
+SET thread_id = (SELECT id FROM threads WHERE key = 'default');
+
+ALTER TABLE comments
+    ALTER COLUMN thread_id SET NOT NULL;
+
+CREATE INDEX idx_comments_thread_roots ON comments(thread_id, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_thread_id ON comments(thread_id);
@@ -0,0 +1,4 @@
+ALTER TABLE comments
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now();
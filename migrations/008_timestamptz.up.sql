@@ -0,0 +1,8 @@
+-- created_at used to come from the client as a timestamp without time zone.
+-- Clients sent UTC, so the stored wall clock is read as UTC.
+UPDATE comments SET created_at = now() AT TIME ZONE 'UTC' WHERE created_at IS NULL;
+
+ALTER TABLE comments
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL;
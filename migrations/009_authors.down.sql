@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_comments_author_id;
+
+ALTER TABLE comments
+    DROP CONSTRAINT comments_deleted_by_fkey;
+
+ALTER TABLE comments
+    DROP COLUMN author_id;
+
+DROP TABLE IF EXISTS authors;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS authors (
+    id BIGSERIAL PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    avatar_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- comments written before authors existed stay anonymous
+ALTER TABLE comments
+    ADD COLUMN author_id BIGINT REFERENCES authors(id);
+
+ALTER TABLE comments
+    ADD CONSTRAINT comments_deleted_by_fkey
+    FOREIGN KEY (deleted_by) REFERENCES authors(id);
+
+CREATE INDEX idx_comments_author_id ON comments(author_id);
@@ -0,0 +1,4 @@
+ALTER TABLE comments
+    DROP COLUMN score;
+
+DROP TABLE IF EXISTS comment_votes;
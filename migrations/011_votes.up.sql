@@ -0,0 +1,14 @@
+-- one vote per author and comment, score on comments is the sum of values
+CREATE TABLE IF NOT EXISTS comment_votes (
+    comment_id BIGINT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    author_id BIGINT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (comment_id, author_id)
+);
+
+CREATE INDEX idx_comment_votes_author_id ON comment_votes(author_id);
+
+ALTER TABLE comments
+    ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
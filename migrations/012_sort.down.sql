@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_comments_thread_roots_controversy;
+DROP INDEX IF EXISTS idx_comments_thread_roots_hot;
+DROP INDEX IF EXISTS idx_comments_thread_roots_top;
+DROP INDEX IF EXISTS idx_comments_parent_controversy;
+DROP INDEX IF EXISTS idx_comments_parent_hot;
+DROP INDEX IF EXISTS idx_comments_parent_top;
+DROP INDEX IF EXISTS idx_comments_parent_created;
+
+ALTER TABLE comments
+    DROP COLUMN controversy,
+    DROP COLUMN hot,
+    DROP COLUMN downvotes,
+    DROP COLUMN upvotes;
+
+DROP FUNCTION IF EXISTS comment_hot(INTEGER, TIMESTAMPTZ);
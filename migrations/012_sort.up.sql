@@ -0,0 +1,53 @@
+-- vote counts feed the controversial sort, score stays upvotes - downvotes
+ALTER TABLE comments
+    ADD COLUMN upvotes INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN downvotes INTEGER NOT NULL DEFAULT 0;
+
+UPDATE comments c
+SET upvotes = v.up, downvotes = v.down
+FROM (
+    SELECT comment_id,
+        count(*) FILTER (WHERE value = 1) AS up,
+        count(*) FILTER (WHERE value = -1) AS down
+    FROM comment_votes
+    GROUP BY comment_id
+) v
+WHERE v.comment_id = c.id;
+
+-- hot is the log of the score plus the creation time, so newer comments need
+-- ten times the score of a comment 12.5 hours older to outrank it. It depends
+-- on stored values only, which lets it be a generated column; the epoch of a
+-- timestamptz does not depend on the session time zone, so the function is
+-- declared immutable.
+CREATE OR REPLACE FUNCTION comment_hot(s INTEGER, ts TIMESTAMPTZ)
+RETURNS DOUBLE PRECISION
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT sign(s::float8) * log(greatest(abs(s), 1)::float8)
+        + extract(epoch FROM ts)::float8 / 45000
+$$;
+
+ALTER TABLE comments
+    ADD COLUMN hot DOUBLE PRECISION
+        GENERATED ALWAYS AS (comment_hot(score, created_at)) STORED;
+
+-- controversy grows with the number of votes and is highest when up and down
+-- votes are balanced, comments voted only one way are not controversial
+ALTER TABLE comments
+    ADD COLUMN controversy DOUBLE PRECISION
+        GENERATED ALWAYS AS (
+            CASE WHEN upvotes = 0 OR downvotes = 0 THEN 0
+            ELSE power((upvotes + downvotes)::float8,
+                least(upvotes, downvotes)::float8 / greatest(upvotes, downvotes))
+            END
+        ) STORED;
+
+-- replies of a comment in every sort order; old and new share one index
+CREATE INDEX idx_comments_parent_created ON comments(parent_id, created_at, id);
+CREATE INDEX idx_comments_parent_top ON comments(parent_id, score DESC, id DESC);
+CREATE INDEX idx_comments_parent_hot ON comments(parent_id, hot DESC, id DESC);
+CREATE INDEX idx_comments_parent_controversy ON comments(parent_id, controversy DESC, id DESC);
+
+-- root comments of a thread, created_at order is idx_comments_thread_roots
+CREATE INDEX idx_comments_thread_roots_top ON comments(thread_id, score DESC, id DESC) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_thread_roots_hot ON comments(thread_id, hot DESC, id DESC) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_thread_roots_controversy ON comments(thread_id, controversy DESC, id DESC) WHERE parent_id IS NULL;
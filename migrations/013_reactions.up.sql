@@ -0,0 +1,10 @@
+-- the allowed reactions come from the config, so reaction is free text here
+CREATE TABLE IF NOT EXISTS comment_reactions (
+    comment_id BIGINT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    author_id BIGINT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    reaction TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (comment_id, author_id, reaction)
+);
+
+CREATE INDEX idx_comment_reactions_author_id ON comment_reactions(author_id);
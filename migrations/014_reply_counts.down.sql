@@ -0,0 +1,4 @@
+ALTER TABLE comments
+    DROP COLUMN last_reply_at,
+    DROP COLUMN descendant_count,
+    DROP COLUMN reply_count;
@@ -0,0 +1,32 @@
+-- reply_count counts direct replies, descendant_count the whole subtree below
+-- a comment and last_reply_at is the newest reply anywhere in it. Tombstones
+-- stay in the tree, so they are counted too.
+ALTER TABLE comments
+    ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN descendant_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_reply_at TIMESTAMPTZ;
+
+WITH RECURSIVE sub (ancestor_id, created_at) AS (
+    SELECT parent_id, created_at FROM comments WHERE parent_id IS NOT NULL
+    UNION ALL
+    SELECT p.parent_id, s.created_at
+    FROM sub s
+    JOIN comments p ON p.id = s.ancestor_id
+    WHERE p.parent_id IS NOT NULL
+), agg AS (
+    SELECT ancestor_id, count(*) AS descendants, max(created_at) AS last_reply_at
+    FROM sub
+    GROUP BY ancestor_id
+), direct AS (
+    SELECT parent_id, count(*) AS replies
+    FROM comments
+    WHERE parent_id IS NOT NULL
+    GROUP BY parent_id
+)
+UPDATE comments c
+SET reply_count = d.replies,
+    descendant_count = a.descendants,
+    last_reply_at = a.last_reply_at
+FROM agg a
+JOIN direct d ON d.parent_id = a.ancestor_id
+WHERE c.id = a.ancestor_id;
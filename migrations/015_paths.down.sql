@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_comments_path;
+DROP INDEX IF EXISTS idx_comments_path_gist;
+
+ALTER TABLE comments
+    DROP COLUMN path;
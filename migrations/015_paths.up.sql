@@ -0,0 +1,27 @@
+CREATE EXTENSION IF NOT EXISTS ltree;
+
+-- path holds the ids from the root down to the comment itself, e.g. 1.5.9,
+-- so ancestors, subtrees and depth need no recursion over parent_id
+ALTER TABLE comments
+    ADD COLUMN path ltree;
+
+WITH RECURSIVE tree AS (
+    SELECT id, id::text::ltree AS path
+    FROM comments
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, t.path || c.id::text
+    FROM comments c
+    JOIN tree t ON c.parent_id = t.id
+)
+UPDATE comments c
+SET path = t.path
+FROM tree t
+WHERE c.id = t.id;
+
+ALTER TABLE comments
+    ALTER COLUMN path SET NOT NULL;
+
+-- GiST serves <@ and @>, btree serves equality and path order
+CREATE INDEX idx_comments_path_gist ON comments USING GIST (path);
+CREATE INDEX idx_comments_path ON comments USING BTREE (path);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_comments_search_vector;
+
+ALTER TABLE comments
+    DROP COLUMN search_vector;
+
+ALTER TABLE comments
+    DROP COLUMN search_config;
+
+ALTER TABLE comments
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (to_tsvector('russian', content)) STORED;
+
+CREATE INDEX idx_comments_search_vector ON comments USING GIN (search_vector);
@@ -0,0 +1,15 @@
+-- every comment remembers the text search configuration it is indexed with,
+-- so changing the configured language does not leave old vectors behind
+-- unnoticed; updating search_config rebuilds the vector of a comment
+ALTER TABLE comments
+    ADD COLUMN search_config regconfig NOT NULL DEFAULT 'russian';
+
+DROP INDEX IF EXISTS idx_comments_search_vector;
+
+ALTER TABLE comments
+    DROP COLUMN search_vector;
+
+ALTER TABLE comments
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (to_tsvector(search_config, content)) STORED;
+
+CREATE INDEX idx_comments_search_vector ON comments USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_comments_search_config;
+
+ALTER TABLE comments
+    DROP COLUMN lang;
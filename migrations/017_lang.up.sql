@@ -0,0 +1,6 @@
+-- lang is the ISO 639-1 code of the detected language of a comment, empty
+-- when it is unknown; search_config follows it when detection is on
+ALTER TABLE comments
+    ADD COLUMN lang TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_comments_search_config ON comments(search_config);
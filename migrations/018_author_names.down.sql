@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_authors_display_name;
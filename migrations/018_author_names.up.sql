@@ -0,0 +1,2 @@
+-- search filters by author:NAME compare display names case-insensitively
+CREATE INDEX idx_authors_display_name ON authors(lower(display_name));